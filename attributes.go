@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	maxCataloguePaths = 1000
+	maxCatalogueDepth = 5
+	interactiveSample = 20 // items sampled on "use" in the shell
+)
+
+// Attribute paths seen in the items of the current table, e.g. "address.city", with the types they
+// had. Paths are written the way they're used in expressions, names which need it are escaped.
+type attributeCatalogue map[string]map[string]bool
+
+// Adds the attributes of items, including the keys of nested maps
+func (c attributeCatalogue) learn(items []map[string]*dynamodb.AttributeValue) {
+	for _, item := range items {
+		c.learnMap("", item, 1)
+	}
+}
+
+func (c attributeCatalogue) learnMap(prefix string, m map[string]*dynamodb.AttributeValue, depth int) {
+	for name, value := range m {
+		if strings.Contains(name, "`") {
+			continue // can't be written in an expression
+		}
+
+		path := formatName(name)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if c[path] == nil {
+			if len(c) >= maxCataloguePaths {
+				continue
+			}
+			c[path] = map[string]bool{}
+		}
+		c[path][attributeType(value)] = true
+
+		if value.M != nil && depth < maxCatalogueDepth {
+			c.learnMap(path, value.M, depth+1)
+		}
+	}
+}
+
+// Paths starting with prefix, in alphabetical order
+func (c attributeCatalogue) matching(prefix string) []string {
+	paths := []string{}
+	for path := range c {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// The types seen for a path, e.g. "N, S"
+func (c attributeCatalogue) types(path string) string {
+	types := []string{}
+	for attributeType := range c[path] {
+		types = append(types, attributeType)
+	}
+	sort.Strings(types)
+
+	return strings.Join(types, ", ")
+}
+
+// The DynamoDB type of a value, e.g. S or NS
+func attributeType(value *dynamodb.AttributeValue) string {
+	switch {
+	case value.S != nil:
+		return "S"
+	case value.N != nil:
+		return "N"
+	case value.B != nil:
+		return "B"
+	case value.BOOL != nil:
+		return "BOOL"
+	case value.NULL != nil:
+		return "NULL"
+	case value.M != nil:
+		return "M"
+	case value.L != nil:
+		return "L"
+	case value.SS != nil:
+		return "SS"
+	case value.NS != nil:
+		return "NS"
+	case value.BS != nil:
+		return "BS"
+	default:
+		return "?"
+	}
+}
+
+// Adds the attributes of items read from table, when it's the current table. Items read from other
+// tables, e.g. by batch-get or sql, are left out so that they don't show up in the completion.
+func (e executor) learnAttributes(table *string, items []map[string]*dynamodb.AttributeValue) {
+	if e.tableCtx.attributes != nil && aws.StringValue(table) == e.tableCtx.name {
+		e.tableCtx.attributes.learn(items)
+	}
+}
+
+// Scans a page of the current table to learn its attributes. Errors are ignored, as this is only
+// used for completion.
+func (e executor) sampleAttributes() {
+	if e.state.sample <= 0 {
+		return
+	}
+
+	input := &dynamodb.ScanInput{TableName: &e.tableCtx.name}
+	input.SetLimit(e.state.sample)
+
+	output, err := e.dynamo.Scan(input)
+	if err == nil {
+		e.learnAttributes(input.TableName, output.Items)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_attributeCatalogue_learn(t *testing.T) {
+	// given
+	catalogue := attributeCatalogue{}
+	items, err := parseItems(`{ pk: 'a', total: 10, address: { city: 'Sofia', geo: { lat: 42.7 } } }
+{ pk: 'b', total: '10', tags: <<'new'>> }`)
+	require.NoError(t, err)
+	items[0]["first name"] = &dynamodb.AttributeValue{S: aws.String("x")}
+	items[1]["has`backtick"] = &dynamodb.AttributeValue{S: aws.String("x")}
+
+	// when
+	catalogue.learn(items)
+
+	// then
+	require.Equal(t, []string{"`first name`", "address", "address.city", "address.geo", "address.geo.lat", "pk", "tags", "total"}, catalogue.matching(""))
+	require.Equal(t, []string{"address.city", "address.geo", "address.geo.lat"}, catalogue.matching("address."))
+	require.Equal(t, "N, S", catalogue.types("total"))
+	require.Equal(t, "M", catalogue.types("address.geo"))
+	require.Equal(t, "SS", catalogue.types("tags"))
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	batchGetSize    = 100
+	batchWriteSize  = 25
+	batchMaxRetries = 8
+)
+
+var (
+	batchBaseDelay = 50 * time.Millisecond
+	batchMaxDelay  = 5 * time.Second
+	sleep          = time.Sleep
+)
+
+// A key or item in a batch request, together with the table it belongs to
+type tableKey struct {
+	table string
+	key   map[string]*dynamodb.AttributeValue
+}
+
+// Output of batch-get, with the responses of all requests merged. Items are set when reading from a
+// single table, Responses when reading from several.
+type batchGetOutput struct {
+	Items            []map[string]*dynamodb.AttributeValue
+	Responses        map[string][]map[string]*dynamodb.AttributeValue
+	ConsumedCapacity []*dynamodb.ConsumedCapacity
+}
+
+// Parses either a list of maps for the current table, e.g. [{ pk: 'a' }, { pk: 'b' }], or a map of
+// table names to lists of maps, e.g. { Orders: [{ pk: 'a' }], Customers: [{ pk: 'b' }] }
+func parseTableMaps(expr string, currentTable string) ([]tableKey, error) {
+	value, err := parseValue(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if value.L != nil {
+		if currentTable == "" {
+			return nil, newValidationError("No table selected! Use a map of table names to lists instead.")
+		}
+		return toTableKeys(currentTable, value)
+	}
+
+	if value.M == nil {
+		return nil, newValidationError("Expected a list, or a map of table names to lists")
+	}
+
+	tables := []string{}
+	for table := range value.M {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	result := []tableKey{}
+	for _, table := range tables {
+		keys, err := toTableKeys(table, value.M[table])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys...)
+	}
+
+	return result, nil
+}
+
+func toTableKeys(table string, list *dynamodb.AttributeValue) ([]tableKey, error) {
+	if list.L == nil {
+		return nil, newValidationError("Expected a list of maps for table %s", table)
+	}
+
+	keys := []tableKey{}
+	for _, key := range list.L {
+		if key.M == nil {
+			return nil, newValidationError("Expected a list of maps for table %s", table)
+		}
+		keys = append(keys, tableKey{table: table, key: key.M})
+	}
+
+	return keys, nil
+}
+
+// Files contain either a list or a map of table names to lists, in the same format as
+// parseTableMaps, or one map per line for the current table
+func parseMapFile(content string, currentTable string) ([]tableKey, error) {
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		return parseTableMaps(content, currentTable)
+	}
+
+	if value, err := parseValue(content); err == nil && isTableMap(value) {
+		return parseTableMaps(content, currentTable)
+	}
+
+	if currentTable == "" {
+		return nil, newValidationError("No table selected!")
+	}
+
+	items, err := parseItems(content)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []tableKey{}
+	for _, item := range items {
+		keys = append(keys, tableKey{table: currentTable, key: item})
+	}
+
+	return keys, nil
+}
+
+// Whether all values of a map are lists of maps, as in a map of table names to lists. Key attributes
+// can't be lists, so a file with a single key is never mistaken for one.
+func isTableMap(value *dynamodb.AttributeValue) bool {
+	if len(value.M) == 0 {
+		return false
+	}
+
+	for _, list := range value.M {
+		if list.L == nil {
+			return false
+		}
+		for _, m := range list.L {
+			if m.M == nil {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Removes duplicate keys, which DynamoDB rejects in a batch
+func uniqueKeys(keys []tableKey) []tableKey {
+	seen := map[string]bool{}
+	unique := []tableKey{}
+
+	for _, key := range keys {
+		id := key.table + "\x00" + formatItem(key.key, binaryBase64)
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, key)
+		}
+	}
+
+	return unique
+}
+
+// Reads keys with BatchGetItem, batchGetSize keys per request. Unprocessed keys are retried with
+// exponential backoff. settings are applied to the request of every table, e.g. the projection.
+func batchGetItems(batchGet func(*dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error),
+	keys []tableKey, settings dynamodb.KeysAndAttributes, consumedCapacity string) (*batchGetOutput, error) {
+
+	result := &batchGetOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{}}
+
+	for start := 0; start < len(keys); start += batchGetSize {
+		end := start + batchGetSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		requestItems := map[string]*dynamodb.KeysAndAttributes{}
+		for _, key := range keys[start:end] {
+			if requestItems[key.table] == nil {
+				tableSettings := settings
+				requestItems[key.table] = &tableSettings
+			}
+			requestItems[key.table].Keys = append(requestItems[key.table].Keys, key.key)
+		}
+
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			if attempt > batchMaxRetries {
+				unprocessed := &dynamodb.BatchGetItemInput{RequestItems: requestItems}
+				return nil, newUnprocessedError("UnprocessedKeys", unprocessed.String(),
+					"Gave up after %d retries, %d keys were not processed", batchMaxRetries, countKeys(requestItems))
+			}
+			if attempt > 0 {
+				sleep(retryDelay(attempt))
+			}
+
+			input := &dynamodb.BatchGetItemInput{RequestItems: requestItems}
+			if consumedCapacity != "" {
+				input.SetReturnConsumedCapacity(consumedCapacity)
+			}
+
+			output, err := batchGet(input)
+			if err != nil {
+				return nil, newAwsError(err, input.String())
+			}
+
+			for table, items := range output.Responses {
+				result.Responses[table] = append(result.Responses[table], items...)
+			}
+			result.ConsumedCapacity = addTableCapacity(result.ConsumedCapacity, output.ConsumedCapacity)
+			requestItems = output.UnprocessedKeys
+		}
+	}
+
+	return result, nil
+}
+
+// Output of batch-write, with the number of items put and deleted
+type batchWriteOutput struct {
+	Put              int64
+	Deleted          int64
+	ConsumedCapacity []*dynamodb.ConsumedCapacity
+}
+
+// A put or delete request in a batch write
+type tableWrite struct {
+	table   string
+	request *dynamodb.WriteRequest
+}
+
+// Fails when an item is written more than once, which DynamoDB rejects in a batch. keys are the key
+// schemas of the tables written to, used to find the keys of put items.
+func validateUniqueWrites(writes []tableWrite, keys map[string]keySchema, binary string) error {
+	seen := map[string]bool{}
+
+	for _, write := range writes {
+		key := write.key(keys[write.table])
+		id := write.table + "\x00" + formatItem(key, binaryBase64)
+		if seen[id] {
+			return newValidationError("Key %s of table %s is written more than once, a batch can only write each item once",
+				formatItem(key, binary), write.table)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// The key of the item written, which for puts is made up of the key attributes of the item
+func (w tableWrite) key(keys keySchema) map[string]*dynamodb.AttributeValue {
+	if w.request.DeleteRequest != nil {
+		return w.request.DeleteRequest.Key
+	}
+
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, attribute := range []string{keys.hashAttribute, keys.rangeAttribute} {
+		if value, ok := w.request.PutRequest.Item[attribute]; ok {
+			key[attribute] = value
+		}
+	}
+
+	return key
+}
+
+// Writes with BatchWriteItem, batchWriteSize requests at a time. Unprocessed items are retried with
+// exponential backoff. progress is called after each batch, with the number of requests written so far.
+func batchWriteItems(batchWrite func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error),
+	writes []tableWrite, consumedCapacity string, progress func(written int, total int)) (*batchWriteOutput, error) {
+
+	result := &batchWriteOutput{}
+
+	for start := 0; start < len(writes); start += batchWriteSize {
+		end := start + batchWriteSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+
+		requestItems := map[string][]*dynamodb.WriteRequest{}
+		for _, write := range writes[start:end] {
+			requestItems[write.table] = append(requestItems[write.table], write.request)
+		}
+
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			// includes the writes of this batch which were processed by earlier attempts
+			written := end - countWrites(requestItems)
+
+			if attempt > batchMaxRetries {
+				unprocessed := &dynamodb.BatchWriteItemInput{RequestItems: requestItems}
+				return nil, newUnprocessedError("UnprocessedItems", unprocessed.String(),
+					"Gave up after %d retries, %d writes were not processed (%d written before)", batchMaxRetries, countWrites(requestItems), written)
+			}
+			if attempt > 0 {
+				sleep(retryDelay(attempt))
+			}
+
+			input := &dynamodb.BatchWriteItemInput{RequestItems: requestItems}
+			if consumedCapacity != "" {
+				input.SetReturnConsumedCapacity(consumedCapacity)
+			}
+
+			output, err := batchWrite(input)
+			if err != nil {
+				return nil, appendToMessage(newAwsError(err, input.String()), " (%d written before)", written)
+			}
+
+			result.ConsumedCapacity = addTableCapacity(result.ConsumedCapacity, output.ConsumedCapacity)
+			requestItems = output.UnprocessedItems
+		}
+
+		for _, write := range writes[start:end] {
+			if write.request.PutRequest != nil {
+				result.Put++
+			} else {
+				result.Deleted++
+			}
+		}
+
+		progress(end, len(writes))
+	}
+
+	return result, nil
+}
+
+func countWrites(requestItems map[string][]*dynamodb.WriteRequest) int {
+	count := 0
+	for _, requests := range requestItems {
+		count += len(requests)
+	}
+
+	return count
+}
+
+func countKeys(requestItems map[string]*dynamodb.KeysAndAttributes) int {
+	count := 0
+	for _, request := range requestItems {
+		count += len(request.Keys)
+	}
+
+	return count
+}
+
+// Delay before a retry, doubling with each attempt up to batchMaxDelay
+func retryDelay(attempt int) time.Duration {
+	delay := batchBaseDelay
+	for i := 1; i < attempt && delay < batchMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > batchMaxDelay {
+		return batchMaxDelay
+	}
+	return delay
+}
+
+// Adds up consumed capacity per table
+func addTableCapacity(total []*dynamodb.ConsumedCapacity, page []*dynamodb.ConsumedCapacity) []*dynamodb.ConsumedCapacity {
+	for _, pageCapacity := range page {
+		found := false
+		for i, totalCapacity := range total {
+			if pageCapacity.TableName != nil && totalCapacity.TableName != nil && *pageCapacity.TableName == *totalCapacity.TableName {
+				total[i] = addConsumedCapacity(totalCapacity, pageCapacity)
+				found = true
+			}
+		}
+		if !found {
+			total = append(total, pageCapacity)
+		}
+	}
+
+	return total
+}
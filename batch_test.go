@@ -0,0 +1,268 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTableMaps_currentTable(t *testing.T) {
+	// when
+	keys, err := parseTableMaps("[{ pk: 'a' }, { pk: 'b' }]", "Orders")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []tableKey{
+		{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": str("a")}},
+		{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": str("b")}},
+	}, keys)
+}
+
+func Test_parseTableMaps_severalTables(t *testing.T) {
+	// when
+	keys, err := parseTableMaps("{ Orders: [{ pk: 'a' }], Customers: [{ id: 1 }] }", "")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []tableKey{
+		{table: "Customers", key: map[string]*dynamodb.AttributeValue{"id": integer(1)}},
+		{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": str("a")}},
+	}, keys)
+}
+
+func Test_parseTableMaps_invalid(t *testing.T) {
+	invalid := map[string]string{
+		"{ pk: 'a' }":          "Expected a list of maps for table pk",
+		"[{ pk: 'a' }, 'b']":   "Expected a list of maps for table Orders",
+		"'a'":                  "Expected a list, or a map of table names to lists",
+		"{ Orders: [{ pk: 1 ]": "Expected ',' or '}'",
+	}
+
+	for expr, msg := range invalid {
+		_, err := parseTableMaps(expr, "Orders")
+		require.Error(t, err, expr)
+		require.Contains(t, err.Error(), msg, expr)
+	}
+
+	_, err := parseTableMaps("[{ pk: 'a' }]", "")
+	require.Equal(t, exitValidation, exitCode(err))
+}
+
+func Test_uniqueKeys(t *testing.T) {
+	keys, err := parseTableMaps("{ A: [{ pk: 'a' }, { pk: 'a' }, { pk: 'b' }], B: [{ pk: 'a' }] }", "")
+	require.NoError(t, err)
+
+	require.Len(t, uniqueKeys(keys), 3)
+}
+
+func Test_batchGetItems_chunksAndRetries(t *testing.T) {
+	// given
+	keys := []tableKey{}
+	for i := 0; i < 250; i++ {
+		keys = append(keys, tableKey{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": integer(i)}})
+	}
+
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	requestSizes := []int{}
+	batchGet := func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		request := input.RequestItems["Orders"]
+		requestSizes = append(requestSizes, len(request.Keys))
+
+		// the first key of each request is left unprocessed once
+		processed := request.Keys
+		unprocessed := map[string]*dynamodb.KeysAndAttributes{}
+		if len(request.Keys) > 1 {
+			processed = request.Keys[1:]
+			unprocessed["Orders"] = &dynamodb.KeysAndAttributes{Keys: request.Keys[:1], ConsistentRead: request.ConsistentRead}
+		}
+
+		return &dynamodb.BatchGetItemOutput{
+			Responses:       map[string][]map[string]*dynamodb.AttributeValue{"Orders": processed},
+			UnprocessedKeys: unprocessed,
+		}, nil
+	}
+
+	// when
+	consistent := true
+	output, err := batchGetItems(batchGet, keys, dynamodb.KeysAndAttributes{ConsistentRead: &consistent}, "")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []int{100, 1, 100, 1, 50, 1}, requestSizes)
+	require.Len(t, output.Responses["Orders"], 250)
+}
+
+func Test_batchGetItems_givesUp(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	batchGet := func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		return &dynamodb.BatchGetItemOutput{UnprocessedKeys: input.RequestItems}, nil
+	}
+
+	keys := []tableKey{{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": integer(1)}}}
+	_, err := batchGetItems(batchGet, keys, dynamodb.KeysAndAttributes{}, "")
+
+	require.EqualError(t, err, "Request throttled, try again later (UnprocessedKeys: Gave up after 8 retries, 1 keys were not processed)")
+	require.Equal(t, exitThrottled, exitCode(err))
+}
+
+func Test_retryDelay(t *testing.T) {
+	require.Equal(t, 50*time.Millisecond, retryDelay(1))
+	require.Equal(t, 100*time.Millisecond, retryDelay(2))
+	require.Equal(t, 400*time.Millisecond, retryDelay(4))
+	require.Equal(t, 5*time.Second, retryDelay(20))
+}
+
+func Test_parseMapFile(t *testing.T) {
+	// when
+	lines, err := parseMapFile("{ pk: 'a' }\n{ pk: 'b', tags: ['x'] }\n\n", "Orders")
+	require.NoError(t, err)
+	list, err := parseMapFile("  [{ pk: 'a' }]", "Orders")
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, lines, 2)
+	require.Equal(t, "Orders", lines[1].table)
+	require.Equal(t, str("b"), lines[1].key["pk"])
+	require.Len(t, list, 1)
+
+	_, err = parseMapFile("{ pk: 'a' }\n{ pk: 'b }", "Orders")
+	require.Contains(t, err.Error(), "(line 2)")
+}
+
+func Test_parseMapFile_severalTables(t *testing.T) {
+	// when
+	keys, err := parseMapFile("{\n  Orders: [{ pk: 'a' }, { pk: 'b' }],\n  Customers: [{ id: 1 }]\n}\n", "Orders")
+	require.NoError(t, err)
+	single, err := parseMapFile("{ pk: 'a', sk: 1 }\n", "")
+
+	// then
+	require.Len(t, keys, 3)
+	require.Equal(t, tableKey{table: "Customers", key: map[string]*dynamodb.AttributeValue{"id": integer(1)}}, keys[0])
+	require.Equal(t, "Orders", keys[2].table)
+	require.Equal(t, str("b"), keys[2].key["pk"])
+	require.Nil(t, single)
+	require.EqualError(t, err, "No table selected!")
+}
+
+func Test_batchWriteItems_chunksAndRetries(t *testing.T) {
+	// given
+	writes := []tableWrite{}
+	for i := 0; i < 60; i++ {
+		item := map[string]*dynamodb.AttributeValue{"pk": integer(i)}
+		if i%2 == 0 {
+			writes = append(writes, tableWrite{table: "Orders", request: &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}})
+		} else {
+			writes = append(writes, tableWrite{table: "Customers", request: &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: item}}})
+		}
+	}
+
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	requestSizes := []int{}
+	batchWrite := func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		requestSizes = append(requestSizes, countWrites(input.RequestItems))
+
+		// Orders requests are left unprocessed once
+		unprocessed := map[string][]*dynamodb.WriteRequest{}
+		if len(input.RequestItems) > 1 {
+			unprocessed["Orders"] = input.RequestItems["Orders"]
+		}
+		return &dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, nil
+	}
+
+	progress := [][]int{}
+
+	// when
+	output, err := batchWriteItems(batchWrite, writes, "", func(written int, total int) {
+		progress = append(progress, []int{written, total})
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []int{25, 13, 25, 12, 10, 5}, requestSizes)
+	require.Equal(t, [][]int{{25, 60}, {50, 60}, {60, 60}}, progress)
+	require.Equal(t, int64(30), output.Put)
+	require.Equal(t, int64(30), output.Deleted)
+}
+
+func Test_batchWriteItems_givesUp(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	batchWrite := func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+	}
+
+	writes := []tableWrite{{table: "Orders", request: &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{"pk": integer(1)}}}}}
+	_, err := batchWriteItems(batchWrite, writes, "", func(int, int) {})
+
+	require.EqualError(t, err, "Request throttled, try again later (UnprocessedItems: Gave up after 8 retries, 1 writes were not processed (0 written before))")
+	require.Equal(t, exitThrottled, exitCode(err))
+}
+
+func Test_batchWriteItems_awsErrorAfterSomeBatches(t *testing.T) {
+	// given
+	writes := []tableWrite{}
+	for i := 0; i < 30; i++ {
+		writes = append(writes, tableWrite{table: "Orders", request: &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{"pk": integer(i)}}}})
+	}
+
+	batchWrite := func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		if countWrites(input.RequestItems) < batchWriteSize {
+			return nil, awserr.New("ValidationException", "Item size has exceeded the maximum allowed size", nil)
+		}
+		return &dynamodb.BatchWriteItemOutput{}, nil
+	}
+
+	// when
+	_, err := batchWriteItems(batchWrite, writes, "", func(int, int) {})
+
+	// then
+	require.EqualError(t, err, "ValidationException: Item size has exceeded the maximum allowed size (25 written before)")
+	require.Equal(t, exitAws, exitCode(err))
+}
+
+func Test_validateUniqueWrites(t *testing.T) {
+	put := func(table string, item string) tableWrite {
+		parsed, err := parseItem(item)
+		require.NoError(t, err)
+		return tableWrite{table: table, request: &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: parsed}}}
+	}
+	del := func(table string, key string) tableWrite {
+		parsed, err := parseItem(key)
+		require.NoError(t, err)
+		return tableWrite{table: table, request: &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: parsed}}}
+	}
+	keys := map[string]keySchema{"Orders": {hashAttribute: "pk", rangeAttribute: "sk"}, "Customers": {hashAttribute: "id"}}
+
+	for _, test := range []struct {
+		writes   []tableWrite
+		expected string
+	}{
+		{[]tableWrite{put("Orders", "{ pk: 'a', sk: 1, total: 1 }"), put("Orders", "{ pk: 'a', sk: 2, total: 1 }"), del("Customers", "{ id: 1 }")}, ""},
+		{[]tableWrite{put("Orders", "{ pk: 'a', sk: 1 }"), put("Customers", "{ id: 1, pk: 'a', sk: 1 }")}, ""},
+		{[]tableWrite{put("Orders", "{ pk: 'a', sk: 1, total: 1 }"), put("Orders", "{ pk: 'a', sk: 1, total: 2 }")},
+			"Key { pk: 'a', sk: 1 } of table Orders is written more than once, a batch can only write each item once"},
+		{[]tableWrite{put("Customers", "{ id: 1, name: 'x' }"), del("Customers", "{ id: 1 }")},
+			"Key { id: 1 } of table Customers is written more than once, a batch can only write each item once"},
+	} {
+		// when
+		err := validateUniqueWrites(test.writes, keys, binaryBase64)
+
+		// then
+		if test.expected == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, test.expected)
+			require.Equal(t, exitValidation, exitCode(err))
+		}
+	}
+}
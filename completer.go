@@ -9,14 +9,13 @@ import (
 	"github.com/c-bata/go-prompt"
 )
 
-var commands []string = []string{"exit", "use", "desc", "query", "scan", "delete", "update", "put"}
-
-func newCompleter(tableCtx *tableContext) completer {
-	return completer{tableCtx: tableCtx}
+func newCompleter(tableCtx *tableContext, aliases map[string]string) completer {
+	return completer{tableCtx: tableCtx, aliases: aliases}
 }
 
 type completer struct {
 	tableCtx *tableContext
+	aliases  map[string]string
 }
 
 func (c completer) complete(doc prompt.Document) []prompt.Suggest {
@@ -28,11 +27,35 @@ func (c completer) complete(doc prompt.Document) []prompt.Suggest {
 		return c.completeCmd(doc)
 	}
 
+	// arguments of aliases are completed as those of the command they stand for
+	if expanded := expandAlias(c.aliases, doc.CurrentLineBeforeCursor()); expanded != doc.CurrentLineBeforeCursor() {
+		buffer := prompt.NewBuffer()
+		buffer.InsertText(expanded, false, true)
+		doc = *buffer.Document()
+	}
+
 	cmd := strings.Split(doc.CurrentLineBeforeCursor(), " ")[0]
 
 	switch cmd {
+	case "set":
+		return c.completeSet(doc)
 	case "use":
 		return c.completeUse(doc)
+	case "create-table":
+		return c.completeFlags(doc, createTableFlags, map[flag][]string{billingFlag: {billingOnDemand, billingProvisioned}})
+	case "update-table":
+		return c.completeFlags(doc, updateTableFlags, map[flag][]string{
+			billingFlag: {billingOnDemand, billingProvisioned},
+			streamFlag:  streamViewTypes,
+		})
+	case "delete-table":
+		return c.completeFlags(doc, getUnusedFlags(doc, &deleteTableOpts{}), map[flag][]string{})
+	case "desc":
+		return c.completeFlags(doc, getUnusedFlags(doc, &descOpts{}), map[flag][]string{})
+	case "get":
+		return c.completeGet(doc)
+	case "batch-get":
+		return c.completeBatchGet(doc)
 	case "query":
 		return c.completeQuery(doc)
 	case "scan":
@@ -43,6 +66,16 @@ func (c completer) complete(doc prompt.Document) []prompt.Suggest {
 		return c.completeUpdate(doc)
 	case "put":
 		return c.completePut(doc)
+	case "batch-write":
+		return c.completeBatchWrite(doc)
+	case "transact-get":
+		return c.completeTransactGet(doc)
+	case "sql", "partiql":
+		return c.completeSql(doc)
+	case "check":
+		return c.completeCheck(doc)
+	case "commit":
+		return c.completeCommit(doc)
 	default:
 		return []prompt.Suggest{}
 	}
@@ -57,6 +90,17 @@ func (c completer) completeCmd(doc prompt.Document) []prompt.Suggest {
 		}
 	}
 
+	aliases := []string{}
+	for alias := range c.aliases {
+		if strings.HasPrefix(alias, doc.CurrentLineBeforeCursor()) {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		matches = append(matches, prompt.Suggest{Text: alias, Description: c.aliases[alias]})
+	}
+
 	// If there were no matches, return all commands
 	if len(matches) == 0 {
 		for _, cmd := range commands {
@@ -67,6 +111,40 @@ func (c completer) completeCmd(doc prompt.Document) []prompt.Suggest {
 	return matches
 }
 
+func (c completer) completeSet(doc prompt.Document) []prompt.Suggest {
+	words := strings.Split(doc.CurrentLineBeforeCursor(), " ")
+
+	settings := map[string][]string{
+		"output": outputFormats,
+		"binary": binaryEncodings,
+		"sample": {"0", "20", "100"},
+	}
+
+	matches := []prompt.Suggest{}
+
+	if len(words) == 2 {
+		for setting := range settings {
+			if strings.HasPrefix(setting, words[1]) {
+				matches = append(matches, prompt.Suggest{Text: setting})
+			}
+		}
+	}
+
+	if len(words) == 3 {
+		for _, value := range settings[words[1]] {
+			if strings.HasPrefix(value, words[2]) {
+				matches = append(matches, prompt.Suggest{Text: value})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Text < matches[j].Text
+	})
+
+	return matches
+}
+
 func (c completer) completeUse(doc prompt.Document) []prompt.Suggest {
 	words := strings.Split(doc.CurrentLineBeforeCursor(), " ")
 
@@ -99,6 +177,40 @@ func (c completer) completeUse(doc prompt.Document) []prompt.Suggest {
 	return matches
 }
 
+func (c completer) completeGet(doc prompt.Document) (suggestions []prompt.Suggest) {
+	matched, suggestions := c.completeKeyFirst(doc, false)
+	if matched {
+		return suggestions
+	}
+
+	matched, suggestions = c.completeKeySecond(doc, false)
+	if matched {
+		return suggestions
+	}
+
+	unusedFlags := getUnusedFlags(doc, &getOpts{})
+	enumFlags := map[flag][]string{}
+
+	capacityFlag := findFlagByShort(getCmdFlags((*getOpts)(nil)), "r")
+	if capacityFlag != nil {
+		enumFlags[*capacityFlag] = []string{"INDEXES", "TOTAL", "NONE"}
+	}
+
+	return c.completeFlags(doc, unusedFlags, enumFlags)
+}
+
+func (c completer) completeBatchGet(doc prompt.Document) (suggestions []prompt.Suggest) {
+	unusedFlags := getUnusedFlags(doc, &batchGetOpts{})
+	enumFlags := map[flag][]string{}
+
+	capacityFlag := findFlagByShort(getCmdFlags((*batchGetOpts)(nil)), "r")
+	if capacityFlag != nil {
+		enumFlags[*capacityFlag] = []string{"INDEXES", "TOTAL", "NONE"}
+	}
+
+	return c.completeFlags(doc, unusedFlags, enumFlags)
+}
+
 func (c completer) completeQuery(doc prompt.Document) (suggestions []prompt.Suggest) {
 	matched, suggestions := c.completeKeyFirst(doc, true)
 	if matched {
@@ -161,6 +273,70 @@ func (c completer) completePut(doc prompt.Document) (suggestions []prompt.Sugges
 	return c.completeWrite(doc, unusedFlags)
 }
 
+func (c completer) completeBatchWrite(doc prompt.Document) (suggestions []prompt.Suggest) {
+	unusedFlags := getUnusedFlags(doc, &batchWriteOpts{})
+	enumFlags := map[flag][]string{}
+
+	capacityFlag := findFlagByShort(getCmdFlags((*batchWriteOpts)(nil)), "r")
+	if capacityFlag != nil {
+		enumFlags[*capacityFlag] = []string{"INDEXES", "TOTAL", "NONE"}
+	}
+
+	return c.completeFlags(doc, unusedFlags, enumFlags)
+}
+
+func (c completer) completeTransactGet(doc prompt.Document) (suggestions []prompt.Suggest) {
+	unusedFlags := getUnusedFlags(doc, &transactGetOpts{})
+	enumFlags := map[flag][]string{}
+
+	capacityFlag := findFlagByShort(getCmdFlags((*transactGetOpts)(nil)), "r")
+	if capacityFlag != nil {
+		enumFlags[*capacityFlag] = []string{"INDEXES", "TOTAL", "NONE"}
+	}
+
+	return c.completeFlags(doc, unusedFlags, enumFlags)
+}
+
+// Only the leading flags are completed, the statement itself isn't
+func (c completer) completeSql(doc prompt.Document) (suggestions []prompt.Suggest) {
+	args := strings.SplitN(doc.CurrentLineBeforeCursor(), " ", 2)[1]
+	if _, statement := splitLeadingFlags(args, &sqlOpts{}); statement != "" {
+		return []prompt.Suggest{}
+	}
+
+	unusedFlags := getUnusedFlags(doc, &sqlOpts{})
+
+	return c.completeFlags(doc, unusedFlags, map[flag][]string{})
+}
+
+func (c completer) completeCheck(doc prompt.Document) (suggestions []prompt.Suggest) {
+	matched, suggestions := c.completeKeyFirst(doc, false)
+	if matched {
+		return suggestions
+	}
+
+	matched, suggestions = c.completeKeySecond(doc, false)
+	if matched {
+		return suggestions
+	}
+
+	unusedFlags := getUnusedFlags(doc, &checkOpts{})
+
+	return c.completeFlags(doc, unusedFlags, map[flag][]string{})
+}
+
+func (c completer) completeCommit(doc prompt.Document) (suggestions []prompt.Suggest) {
+	unusedFlags := getUnusedFlags(doc, &commitOpts{})
+	enumFlags := map[flag][]string{}
+
+	capacityFlag := findFlagByShort(getCmdFlags((*commitOpts)(nil)), "r")
+	if capacityFlag != nil {
+		enumFlags[*capacityFlag] = []string{"INDEXES", "TOTAL", "NONE"}
+	}
+
+	return c.completeFlags(doc, unusedFlags, enumFlags)
+}
+
 func (c completer) completeWrite(doc prompt.Document, unusedFlags []flag) (suggestions []prompt.Suggest) {
 	matched, suggestions := c.completeKeyFirst(doc, false)
 	if matched {
@@ -186,7 +362,7 @@ func (c completer) completeWrite(doc prompt.Document, unusedFlags []flag) (sugge
 
 func (c completer) completeFlags(doc prompt.Document, unusedFlags []flag, enumFlags map[flag][]string) (suggestions []prompt.Suggest) {
 	if isInParameter(doc) {
-		return []prompt.Suggest{}
+		return c.completeExpression(doc)
 	}
 
 	matched, suggestions := completeFlag(doc, unusedFlags)
@@ -202,6 +378,133 @@ func (c completer) completeFlags(doc prompt.Document, unusedFlags []flag, enumFl
 	return []prompt.Suggest{}
 }
 
+// Separates the word being completed from the text before it. Besides spaces and quotes, this
+// includes the symbols of expressions, so that names can be completed after them.
+const completionWordSeparator = " \"{-(),=<>+[]"
+
+// Flags whose values are expressions, by long name
+var expressionFlags = []string{"filter", "projection", "update", "condition-expression"}
+
+// Options of the commands with expression flags, used to find the long name of a short flag
+var commandOpts = map[string]interface{}{
+	"get":          &getOpts{},
+	"batch-get":    &batchGetOpts{},
+	"transact-get": &transactGetOpts{},
+	"query":        &queryOpts{},
+	"scan":         &scanOpts{},
+	"delete":       &deleteOpts{},
+	"update":       &updateOpts{},
+	"put":          &putOpts{},
+	"check":        &checkOpts{},
+}
+
+var rgxAttributeTypeArg = regexp.MustCompile(`attribute_type\(\s*[^,()]*,\s*'?[A-Z]*$`)
+
+// Completes inside the value of an expression flag: attribute names, function names with their
+// signatures, the type codes of attribute_type, and the clauses of update expressions
+func (c completer) completeExpression(doc prompt.Document) []prompt.Suggest {
+	flagName := c.currentFlag(doc)
+	if !contains(expressionFlags, flagName) {
+		return []prompt.Suggest{}
+	}
+
+	word := doc.GetWordBeforeCursorUntilSeparator(completionWordSeparator)
+	before := strings.TrimSpace(strings.TrimSuffix(doc.CurrentLineBeforeCursor(), word))
+	suggestions := []prompt.Suggest{}
+
+	if rgxAttributeTypeArg.MatchString(doc.CurrentLineBeforeCursor()) {
+		for _, code := range attributeTypeCodes {
+			if strings.HasPrefix("'"+code[0]+"'", word) {
+				suggestions = append(suggestions, prompt.Suggest{Text: "'" + code[0] + "'", Description: code[1]})
+			}
+		}
+		return suggestions
+	}
+
+	var functions []string
+	switch flagName {
+	case "update":
+		if isClausePosition(before) {
+			for _, clause := range updateClauses {
+				if strings.HasPrefix(clause, strings.ToUpper(word)) {
+					suggestions = append(suggestions, prompt.Suggest{Text: clause, Description: "clause"})
+				}
+			}
+			return suggestions
+		}
+		functions = updateFunctions
+	case "filter", "condition-expression":
+		functions = conditionFunctions
+	}
+
+	for _, function := range functions {
+		if strings.HasPrefix(function, word) {
+			suggestions = append(suggestions, prompt.Suggest{Text: function, Description: functionSignatures[function]})
+		}
+	}
+
+	if c.tableCtx.attributes != nil {
+		for _, path := range c.tableCtx.attributes.matching(word) {
+			suggestions = append(suggestions, prompt.Suggest{Text: path, Description: c.tableCtx.attributes.types(path)})
+		}
+	}
+
+	return suggestions
+}
+
+// Whether a clause of an update expression can start after before, which is the case at the start
+// of the expression, or after a complete action such as "SET a = 1"
+func isClausePosition(before string) bool {
+	if before == "" || strings.HasSuffix(before, "\"") {
+		return true
+	}
+
+	fields := strings.Fields(before)
+	if contains(updateClauses, strings.ToUpper(strings.TrimLeft(fields[len(fields)-1], "\""))) {
+		return false
+	}
+
+	return !strings.ContainsAny(before[len(before)-1:], "=,+-(")
+}
+
+// The long name of the flag whose quoted value the cursor is in, e.g. "filter" for -f "a = 1 AND
+func (c completer) currentFlag(doc prompt.Document) string {
+	line := doc.CurrentLineBeforeCursor()
+
+	flagToken := ""
+	inQuote := false
+	for pos, char := range line {
+		if char != '"' || (pos > 0 && line[pos-1] == '\\') {
+			continue
+		}
+		inQuote = !inQuote
+		if inQuote {
+			before := strings.Fields(strings.TrimSuffix(line[:pos], "="))
+			flagToken = ""
+			if len(before) > 0 {
+				flagToken = strings.TrimSuffix(before[len(before)-1], "=")
+			}
+		}
+	}
+
+	if strings.HasPrefix(flagToken, "--") {
+		return flagToken[2:]
+	}
+	if !strings.HasPrefix(flagToken, "-") {
+		return ""
+	}
+
+	opts, ok := commandOpts[strings.Fields(line)[0]]
+	if !ok {
+		return ""
+	}
+	if cmdFlag := findFlagByShort(getCmdFlags(opts), flagToken[1:]); cmdFlag != nil {
+		return cmdFlag.long
+	}
+
+	return ""
+}
+
 func completeFlag(doc prompt.Document, unusedFlags []flag) (matched bool, suggestions []prompt.Suggest) {
 	var rgxFlag = regexp.MustCompile(`.* (-{1,2})([a-zA-Z]*)$`)
 	allMatches := rgxFlag.FindAllStringSubmatch(doc.CurrentLineBeforeCursor(), -1)
@@ -244,12 +547,13 @@ func (c completer) completeKeyFirst(doc prompt.Document, isExpression bool) (mat
 		return false, []prompt.Suggest{}
 	}
 
+	keys := c.tableCtx.keys(selectedIndex(doc))
 	keyInput := matches[len(matches)-1]
-	if strings.HasPrefix(c.tableCtx.hashAttribute, keyInput) {
-		suggestions = append(suggestions, prompt.Suggest{Text: c.tableCtx.hashAttribute, Description: "pk"})
+	if strings.HasPrefix(keys.hashAttribute, keyInput) {
+		suggestions = append(suggestions, c.keySuggestion(keys.hashAttribute, "pk"))
 	}
-	if c.tableCtx.rangeAttribute != "" && strings.HasPrefix(c.tableCtx.rangeAttribute, keyInput) {
-		suggestions = append(suggestions, prompt.Suggest{Text: c.tableCtx.rangeAttribute, Description: "sk"})
+	if keys.rangeAttribute != "" && strings.HasPrefix(keys.rangeAttribute, keyInput) {
+		suggestions = append(suggestions, c.keySuggestion(keys.rangeAttribute, "sk"))
 	}
 
 	return true, suggestions
@@ -276,21 +580,43 @@ func (c completer) completeKeySecond(doc prompt.Document, isExpression bool) (ma
 		firstCondition = matches[3]
 	}
 
+	keys := c.tableCtx.keys(selectedIndex(doc))
 	keyInput := matches[len(matches)-1]
-	if strings.HasPrefix(c.tableCtx.hashAttribute, keyInput) {
-		if !strings.Contains(firstCondition, c.tableCtx.hashAttribute) {
-			suggestions = append(suggestions, prompt.Suggest{Text: c.tableCtx.hashAttribute, Description: "pk"})
+	if strings.HasPrefix(keys.hashAttribute, keyInput) {
+		if !strings.Contains(firstCondition, keys.hashAttribute) {
+			suggestions = append(suggestions, c.keySuggestion(keys.hashAttribute, "pk"))
 		}
 	}
-	if c.tableCtx.rangeAttribute != "" && strings.HasPrefix(c.tableCtx.rangeAttribute, keyInput) {
-		if !strings.Contains(firstCondition, c.tableCtx.rangeAttribute) {
-			suggestions = append(suggestions, prompt.Suggest{Text: c.tableCtx.rangeAttribute, Description: "sk"})
+	if keys.rangeAttribute != "" && strings.HasPrefix(keys.rangeAttribute, keyInput) {
+		if !strings.Contains(firstCondition, keys.rangeAttribute) {
+			suggestions = append(suggestions, c.keySuggestion(keys.rangeAttribute, "sk"))
 		}
 	}
 
 	return true, suggestions
 }
 
+// Suggests a key attribute, with its type when it's known, e.g. "pk (S)"
+func (c completer) keySuggestion(attribute string, description string) prompt.Suggest {
+	if attributeType := c.tableCtx.attributeTypes[attribute]; attributeType != "" {
+		description += " (" + attributeType + ")"
+	}
+
+	return prompt.Suggest{Text: attribute, Description: description}
+}
+
+var rgxIndexFlag = regexp.MustCompile(` (-i|--index)(=| +)"?([^ "]+)`)
+
+// The index given with -i anywhere in the command, or "" when reading from the table
+func selectedIndex(doc prompt.Document) string {
+	matches := rgxIndexFlag.FindAllStringSubmatch(doc.Text, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[len(matches)-1][3]
+}
+
 func completeEnum(doc prompt.Document, enumVals map[flag][]string) (matched bool, suggestions []prompt.Suggest) {
 	keys := make([]string, len(enumVals)*2)
 
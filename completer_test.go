@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/stretchr/testify/require"
+)
+
+func document(text string) prompt.Document {
+	buf := prompt.NewBuffer()
+	buf.InsertText(text, false, true)
+
+	return *buf.Document()
+}
+
+func Test_isClausePosition(t *testing.T) {
+	for before, expected := range map[string]bool{
+		"":                           true,
+		`update -k "{ pk: 1 }" -u "`: true,
+		"SET a = 1":                  true,
+		"SET a = 1, b = b + 1":       true,
+		"SET a = list_append(a, b)":  true,
+		"SET":                        false,
+		`"SET`:                       false,
+		"SET a = 1,":                 false,
+		"SET a =":                    false,
+		"SET a = b +":                false,
+		"SET a = list_append(":       false,
+		"remove":                     false,
+	} {
+		require.Equal(t, expected, isClausePosition(before), before)
+	}
+}
+
+func Test_completer_currentFlag(t *testing.T) {
+	c := newCompleter(&tableContext{}, nil)
+
+	for line, expected := range map[string]string{
+		`scan --filter "a = 1 AND `:          "filter",
+		`scan --filter="a = 1 AND `:          "filter",
+		`scan -f "a = 1 AND `:                "filter",
+		`scan -f="a = 1 AND `:                "filter",
+		`update -k "{ pk: 1 }" -u "SET a = `: "update",
+		`delete -k "{ pk: 1 }" -c "`:         "condition-expression",
+		`scan -p "pk" -f "a = \"b`:           "filter",
+		`scan -x "`:                          "",
+		`unknown -f "`:                       "",
+		`scan`:                               "",
+	} {
+		require.Equal(t, expected, c.currentFlag(document(line)), line)
+	}
+}
+
+func Test_rgxAttributeTypeArg(t *testing.T) {
+	for line, expected := range map[string]bool{
+		`scan -f "attribute_type(a, `:       true,
+		`scan -f "attribute_type(a,`:        true,
+		`scan -f "attribute_type(a, '`:      true,
+		`scan -f "attribute_type(a, 'N`:     true,
+		`scan -f "attribute_type( a.b[0], `: true,
+		`scan -f "attribute_type(`:          false,
+		`scan -f "attribute_type(a`:         false,
+		`scan -f "attribute_type(a, 'N') `:  false,
+		`scan -f "attribute_exists(a, `:     false,
+	} {
+		require.Equal(t, expected, rgxAttributeTypeArg.MatchString(line), line)
+	}
+}
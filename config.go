@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Settings read from the config file, e.g.
+//
+//	region = eu-west-1
+//	endpoint = local
+//
+//	[endpoints]
+//	local = http://localhost:4566
+//
+//	[aliases]
+//	orders = use Orders
+type config struct {
+	region    string
+	profile   string
+	endpoint  string // a URL, or the name of one of the endpoints
+	output    string
+	table     string
+	endpoints map[string]string
+	aliases   map[string]string // commands which a name is replaced with, followed by its arguments
+}
+
+// The directory dynshell keeps its files in, e.g. ~/.config/dynshell
+func configDir() (string, error) {
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userConfigDir, "dynshell"), nil
+}
+
+// Reads the config file at path. The default file doesn't need to exist, while one given with
+// --config does.
+func loadConfig(path string, isDefault bool) (config, error) {
+	cfg := config{endpoints: map[string]string{}, aliases: map[string]string{}}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && isDefault {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer file.Close()
+
+	if err := cfg.parse(bufio.NewScanner(file)); err != nil {
+		return cfg, fmt.Errorf("%s:%v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Parses lines of "key = value", in sections started by "[name]". Lines starting with '#' or ';'
+// are comments.
+func (cfg *config) parse(scanner *bufio.Scanner) error {
+	section := ""
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section != "endpoints" && section != "aliases" {
+				return fmt.Errorf("%d: Unknown section [%s], expected [endpoints] or [aliases]", lineNumber, section)
+			}
+			continue
+		}
+
+		separatorIdx := strings.Index(line, "=")
+		if separatorIdx == -1 {
+			return fmt.Errorf("%d: Expected key = value", lineNumber)
+		}
+		key := strings.TrimSpace(line[:separatorIdx])
+		value := strings.TrimSpace(line[separatorIdx+1:])
+
+		if key == "" || value == "" {
+			return fmt.Errorf("%d: Expected key = value", lineNumber)
+		}
+
+		if err := cfg.set(section, key, value); err != nil {
+			return fmt.Errorf("%d: %v", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (cfg *config) set(section string, key string, value string) error {
+	switch section {
+	case "endpoints":
+		cfg.endpoints[key] = value
+		return nil
+	case "aliases":
+		if strings.Contains(key, " ") {
+			return fmt.Errorf("Alias names can't contain spaces: %s", key)
+		}
+		if isCommand(key) {
+			return fmt.Errorf("Alias %s would hide the %s command", key, key)
+		}
+		cfg.aliases[key] = value
+		return nil
+	}
+
+	switch key {
+	case "region":
+		cfg.region = value
+	case "profile":
+		cfg.profile = value
+	case "endpoint":
+		cfg.endpoint = value
+	case "output":
+		if !contains(outputFormats, value) {
+			return fmt.Errorf("Unknown output format: %s, expected one of: %s", value, strings.Join(outputFormats, ", "))
+		}
+		cfg.output = value
+	case "table":
+		cfg.table = value
+	default:
+		return fmt.Errorf("Unknown setting: %s", key)
+	}
+
+	return nil
+}
+
+// Fills in the options which weren't given on the command line from the config file, and replaces
+// endpoint names with their URLs. The region is left empty when neither sets it, so that it's read
+// from the AWS config.
+func (cfg config) apply(opts opts) opts {
+	if opts.Region == "" {
+		opts.Region = cfg.region
+	}
+	if opts.Profile == "" {
+		opts.Profile = cfg.profile
+	}
+	if opts.EndpointUrl == "" {
+		opts.EndpointUrl = cfg.endpoint
+	}
+	if url, ok := cfg.endpoints[opts.EndpointUrl]; ok {
+		opts.EndpointUrl = url
+	}
+	if opts.Output == "" {
+		opts.Output = cfg.output
+	}
+	if opts.Output == "" {
+		opts.Output = outputNative
+	}
+	if opts.Table == "" {
+		opts.Table = cfg.table
+	}
+
+	return opts
+}
+
+// Replaces an alias at the start of a command with the command it stands for. Aliases aren't
+// expanded again, so they can't refer to each other.
+func expandAlias(aliases map[string]string, input string) string {
+	name, args := input, ""
+	if separatorIdx := strings.Index(input, " "); separatorIdx != -1 {
+		name, args = input[:separatorIdx], input[separatorIdx:]
+	}
+
+	if command, ok := aliases[name]; ok {
+		return command + args
+	}
+
+	return input
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadConfig(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "config")
+	content := `# defaults
+region = eu-west-1
+profile = dev
+endpoint = local
+output = json
+table = Orders
+
+[endpoints]
+local = http://localhost:4566
+
+[aliases]
+o = use Orders
+by-status = query -i byStatus -k "status = 'open'"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	// when
+	cfg, err := loadConfig(path, false)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, config{
+		region:    "eu-west-1",
+		profile:   "dev",
+		endpoint:  "local",
+		output:    "json",
+		table:     "Orders",
+		endpoints: map[string]string{"local": "http://localhost:4566"},
+		aliases:   map[string]string{"o": "use Orders", "by-status": "query -i byStatus -k \"status = 'open'\""},
+	}, cfg)
+}
+
+func Test_loadConfig_missing(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "config")
+
+	// when
+	_, defaultErr := loadConfig(path, true)
+	_, givenErr := loadConfig(path, false)
+
+	// then
+	require.NoError(t, defaultErr)
+	require.Error(t, givenErr)
+}
+
+func Test_config_parse_errors(t *testing.T) {
+	for content, expected := range map[string]string{
+		"regoin = eu-west-1":            "1: Unknown setting: regoin",
+		"\nregion":                      "2: Expected key = value",
+		"output = yaml":                 "1: Unknown output format: yaml, expected one of: native, json, dynamodb-json, jsonl, csv, table",
+		"[endpoint]\nlocal = http://x":  "1: Unknown section [endpoint], expected [endpoints] or [aliases]",
+		"[aliases]\nscan = query -k pk": "2: Alias scan would hide the scan command",
+		"[aliases]\nq = scan -l 10":     "2: Alias q would hide the q command",
+		"[aliases]\nmore = next":        "2: Alias more would hide the more command",
+		"[aliases]\nquit = exit":        "2: Alias quit would hide the quit command",
+	} {
+		// given
+		cfg := config{endpoints: map[string]string{}, aliases: map[string]string{}}
+
+		// when
+		err := cfg.parse(bufio.NewScanner(strings.NewReader(content)))
+
+		// then
+		require.EqualError(t, err, expected)
+	}
+}
+
+func Test_config_apply(t *testing.T) {
+	// given
+	cfg := config{
+		region:    "eu-west-1",
+		endpoint:  "local",
+		output:    "json",
+		table:     "Orders",
+		endpoints: map[string]string{"local": "http://localhost:4566"},
+	}
+
+	// when
+	defaults := cfg.apply(opts{})
+	overridden := cfg.apply(opts{Region: "us-east-1", EndpointUrl: "http://localhost:8000", Output: "csv", Table: "Customers"})
+
+	// then
+	require.Equal(t, opts{Region: "eu-west-1", EndpointUrl: "http://localhost:4566", Output: "json", Table: "Orders"}, defaults)
+	require.Equal(t, opts{Region: "us-east-1", EndpointUrl: "http://localhost:8000", Output: "csv", Table: "Customers"}, overridden)
+	require.Equal(t, outputNative, config{}.apply(opts{}).Output)
+}
+
+func Test_expandAlias(t *testing.T) {
+	aliases := map[string]string{"o": "use Orders", "s": "scan -l 10", "loop": "s"}
+
+	for input, expected := range map[string]string{
+		"o":            "use Orders",
+		"s -p pk":      "scan -l 10 -p pk",
+		"loop":         "s",
+		"scan -l 10":   "scan -l 10",
+		"orders scan ": "orders scan ",
+	} {
+		// when
+		expanded := expandAlias(aliases, input)
+
+		// then
+		require.Equal(t, expected, expanded)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type descOpts struct {
+	Raw bool `long:"raw" description:"Show the full DescribeTable output" required:"false"`
+}
+
+// Table settings which aren't part of DescribeTable. Each is nil when it couldn't be read, e.g.
+// because DynamoDB Local doesn't support it.
+type tableSettings struct {
+	ttl     *dynamodb.TimeToLiveDescription
+	backups *dynamodb.ContinuousBackupsDescription
+	tags    []*dynamodb.Tag
+}
+
+func (e executor) handleDesc(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
+
+	descOpts := descOpts{}
+
+	if proceed, err := parseFlags(&descOpts, args); !proceed {
+		return err
+	}
+
+	describeInput := dynamodb.DescribeTableInput{
+		TableName: &e.tableCtx.name,
+	}
+
+	describeOutput, err := e.dynamo.DescribeTable(&describeInput)
+	if err != nil {
+		return newAwsError(err, describeInput.String())
+	}
+
+	// The summary is only for reading, other formats get the full output so that it can be processed
+	if descOpts.Raw || e.state.output != outputNative {
+		return e.printOutput(describeOutput)
+	}
+
+	fmt.Print(formatTableSummary(describeOutput.Table, e.describeSettings(describeOutput.Table)))
+
+	return nil
+}
+
+// Reads the settings shown in the summary, leaving out the ones that can't be read
+func (e executor) describeSettings(table *dynamodb.TableDescription) tableSettings {
+	settings := tableSettings{}
+
+	ttlOutput, err := e.dynamo.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: table.TableName})
+	if err == nil {
+		settings.ttl = ttlOutput.TimeToLiveDescription
+	}
+
+	backupsOutput, err := e.dynamo.DescribeContinuousBackups(&dynamodb.DescribeContinuousBackupsInput{TableName: table.TableName})
+	if err == nil {
+		settings.backups = backupsOutput.ContinuousBackupsDescription
+	}
+
+	if table.TableArn != nil {
+		if tags, err := e.listTags(table.TableArn); err == nil {
+			settings.tags = tags
+		}
+	}
+
+	return settings
+}
+
+func (e executor) listTags(arn *string) ([]*dynamodb.Tag, error) {
+	tags := []*dynamodb.Tag{}
+	input := &dynamodb.ListTagsOfResourceInput{ResourceArn: arn}
+
+	for {
+		output, err := e.dynamo.ListTagsOfResource(input)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, output.Tags...)
+
+		if output.NextToken == nil {
+			return tags, nil
+		}
+		input.SetNextToken(*output.NextToken)
+	}
+}
+
+// Formats a table description as a summary of the table's keys, capacity, indexes and settings
+func formatTableSummary(table *dynamodb.TableDescription, settings tableSettings) string {
+	var sb strings.Builder
+
+	types := map[string]string{}
+	for _, definition := range table.AttributeDefinitions {
+		types[aws.StringValue(definition.AttributeName)] = aws.StringValue(definition.AttributeType)
+	}
+
+	line := func(indent string, label string, value string) {
+		fmt.Fprintf(&sb, "%s%-12s%s\n", indent, label+":", value)
+	}
+
+	line("", "Table", fmt.Sprintf("%s (%s)", aws.StringValue(table.TableName), strings.ToLower(aws.StringValue(table.TableStatus))))
+	line("", "Keys", formatKeySchema(table.KeySchema, types))
+
+	if table.BillingModeSummary != nil && aws.StringValue(table.BillingModeSummary.BillingMode) == dynamodb.BillingModePayPerRequest {
+		line("", "Billing", "on-demand")
+	} else {
+		line("", "Billing", "provisioned, "+formatThroughput(table.ProvisionedThroughput))
+	}
+
+	line("", "Items", fmt.Sprintf("%s (%s, updated about every 6 hours)",
+		formatCount(aws.Int64Value(table.ItemCount)), formatBytes(aws.Int64Value(table.TableSizeBytes))))
+
+	if stream := table.StreamSpecification; stream != nil && aws.BoolValue(stream.StreamEnabled) {
+		line("", "Stream", strings.ToLower(aws.StringValue(stream.StreamViewType)))
+	} else {
+		line("", "Stream", "disabled")
+	}
+
+	switch {
+	case settings.ttl == nil:
+		line("", "TTL", "unknown")
+	case aws.StringValue(settings.ttl.TimeToLiveStatus) == dynamodb.TimeToLiveStatusDisabled:
+		line("", "TTL", "disabled")
+	default:
+		line("", "TTL", fmt.Sprintf("%s (%s)", aws.StringValue(settings.ttl.AttributeName), strings.ToLower(aws.StringValue(settings.ttl.TimeToLiveStatus))))
+	}
+
+	if settings.backups == nil || settings.backups.PointInTimeRecoveryDescription == nil {
+		line("", "PITR", "unknown")
+	} else {
+		line("", "PITR", strings.ToLower(aws.StringValue(settings.backups.PointInTimeRecoveryDescription.PointInTimeRecoveryStatus)))
+	}
+
+	if settings.tags == nil {
+		line("", "Tags", "unknown")
+	} else {
+		line("", "Tags", formatTags(settings.tags))
+	}
+
+	if len(table.GlobalSecondaryIndexes)+len(table.LocalSecondaryIndexes) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("Indexes:\n")
+
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if gsi.IndexStatus != nil {
+			fmt.Fprintf(&sb, "  %s (global, %s)\n", aws.StringValue(gsi.IndexName), strings.ToLower(*gsi.IndexStatus))
+		} else {
+			fmt.Fprintf(&sb, "  %s (global)\n", aws.StringValue(gsi.IndexName))
+		}
+		line("    ", "Keys", formatKeySchema(gsi.KeySchema, types))
+		line("    ", "Projection", formatProjection(gsi.Projection))
+		if gsi.ProvisionedThroughput != nil && aws.Int64Value(gsi.ProvisionedThroughput.ReadCapacityUnits) > 0 {
+			line("    ", "Capacity", formatThroughput(gsi.ProvisionedThroughput))
+		}
+	}
+
+	for _, lsi := range table.LocalSecondaryIndexes {
+		fmt.Fprintf(&sb, "  %s (local)\n", aws.StringValue(lsi.IndexName))
+		line("    ", "Keys", formatKeySchema(lsi.KeySchema, types))
+		line("    ", "Projection", formatProjection(lsi.Projection))
+	}
+
+	return sb.String()
+}
+
+// e.g. "pk (S, hash), sk (N, range)"
+func formatKeySchema(keySchema []*dynamodb.KeySchemaElement, types map[string]string) string {
+	keys := []string{}
+	for _, key := range keySchema {
+		name := aws.StringValue(key.AttributeName)
+		keyType := strings.ToLower(aws.StringValue(key.KeyType))
+		if types[name] != "" {
+			keyType = types[name] + ", " + keyType
+		}
+		keys = append(keys, fmt.Sprintf("%s (%s)", name, keyType))
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+func formatThroughput(throughput *dynamodb.ProvisionedThroughputDescription) string {
+	if throughput == nil {
+		return "unknown capacity"
+	}
+
+	return fmt.Sprintf("%d read / %d write units", aws.Int64Value(throughput.ReadCapacityUnits), aws.Int64Value(throughput.WriteCapacityUnits))
+}
+
+func formatProjection(projection *dynamodb.Projection) string {
+	if projection == nil {
+		return "unknown"
+	}
+
+	projectionType := strings.ToLower(strings.ReplaceAll(aws.StringValue(projection.ProjectionType), "_", " "))
+	if len(projection.NonKeyAttributes) > 0 {
+		projectionType += ": " + strings.Join(aws.StringValueSlice(projection.NonKeyAttributes), ", ")
+	}
+
+	return projectionType
+}
+
+func formatTags(tags []*dynamodb.Tag) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+
+	formatted := []string{}
+	for _, tag := range tags {
+		formatted = append(formatted, aws.StringValue(tag.Key)+"="+aws.StringValue(tag.Value))
+	}
+	sort.Strings(formatted)
+
+	return strings.Join(formatted, ", ")
+}
+
+// Formats a count with thousands separators, e.g. 1,234,567
+func formatCount(count int64) string {
+	digits := strconv.FormatInt(count, 10)
+
+	var sb strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 && digits[i-1] != '-' {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+
+	return sb.String()
+}
+
+// Formats a size in bytes in the largest unit it's at least one of, e.g. 1.5 MB
+func formatBytes(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	size := float64(bytes)
+	units := []string{"KB", "MB", "GB", "TB", "PB"}
+	unit := ""
+	for _, unit = range units {
+		size /= 1024
+		if size < 1024 {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%.1f %s", size, unit)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_formatTableSummary(t *testing.T) {
+	// given
+	table := &dynamodb.TableDescription{
+		TableName:   aws.String("Orders"),
+		TableStatus: aws.String("ACTIVE"),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("createdAt"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("customerId"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")},
+		},
+		BillingModeSummary: &dynamodb.BillingModeSummary{BillingMode: aws.String("PAY_PER_REQUEST")},
+		ItemCount:          aws.Int64(1234567),
+		TableSizeBytes:     aws.Int64(1572864),
+		StreamSpecification: &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String("NEW_AND_OLD_IMAGES"),
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName:   aws.String("byCustomer"),
+			IndexStatus: aws.String("CREATING"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("customerId"), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String("createdAt"), KeyType: aws.String("RANGE")},
+			},
+			Projection: &dynamodb.Projection{ProjectionType: aws.String("INCLUDE"), NonKeyAttributes: aws.StringSlice([]string{"total", "status"})},
+		}},
+	}
+	settings := tableSettings{
+		ttl:  &dynamodb.TimeToLiveDescription{TimeToLiveStatus: aws.String("DISABLED")},
+		tags: []*dynamodb.Tag{{Key: aws.String("team"), Value: aws.String("orders")}, {Key: aws.String("env"), Value: aws.String("dev")}},
+	}
+
+	// when
+	summary := formatTableSummary(table, settings)
+
+	// then
+	require.Equal(t, `Table:      Orders (active)
+Keys:       pk (S, hash)
+Billing:    on-demand
+Items:      1,234,567 (1.5 MB, updated about every 6 hours)
+Stream:     new_and_old_images
+TTL:        disabled
+PITR:       unknown
+Tags:       env=dev, team=orders
+Indexes:
+  byCustomer (global, creating)
+    Keys:       customerId (S, hash), createdAt (N, range)
+    Projection: include: total, status
+`, summary)
+}
+
+func Test_formatBytes(t *testing.T) {
+	for bytes, expected := range map[int64]string{
+		0:                  "0 B",
+		1023:               "1023 B",
+		1024:               "1.0 KB",
+		5 * 1024 * 1024:    "5.0 MB",
+		3 << 40:            "3.0 TB",
+		1536 * 1024 * 1024: "1.5 GB",
+	} {
+		require.Equal(t, expected, formatBytes(bytes))
+	}
+}
+
+func Test_executor_desc_outputFormat(t *testing.T) {
+	// given
+	dynamo := mockDynamo(func(operation string, input interface{}) interface{} {
+		return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+			TableName: aws.String("Orders"),
+			KeySchema: []*dynamodb.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")}},
+		}}
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders", hashAttribute: "pk"}, outputNative, false)
+
+	// when
+	var setErr, descErr error
+	out := captureStdout(t, func() {
+		setErr = e.run("set output json")
+		descErr = e.run("desc")
+	})
+
+	// then
+	require.NoError(t, setErr)
+	require.NoError(t, descErr)
+	var described map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &described))
+	require.Equal(t, "Orders", described["Table"]["TableName"])
+}
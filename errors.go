@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Exit codes used in non-interactive mode
+const (
+	exitOk         = 0
+	exitError      = 1
+	exitValidation = 2
+	exitParse      = 3
+	exitAws        = 4
+	exitThrottled  = 5
+)
+
+// Invalid command input, e.g. unknown flags or no table selected
+type validationError struct {
+	msg string
+}
+
+func newValidationError(format string, args ...interface{}) error {
+	return &validationError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+// An expression or value which could not be parsed. When the parsed expression is known, the error
+// is displayed with the expression and a caret under the offending position.
+type parseError struct {
+	msg    string
+	flag   string
+	expr   string
+	offset int
+}
+
+func newParseError(format string, args ...interface{}) error {
+	return &parseError{msg: fmt.Sprintf(format, args...), offset: -1}
+}
+
+// offset is the byte offset in expr at which parsing failed
+func newParseErrorAt(expr string, offset int, format string, args ...interface{}) error {
+	return &parseError{msg: fmt.Sprintf(format, args...), expr: expr, offset: offset}
+}
+
+func (e *parseError) Error() string {
+	msg := e.msg
+	if e.flag != "" {
+		msg = "Could not parse " + e.flag + ": " + msg
+	}
+
+	if e.offset < 0 {
+		return msg
+	}
+
+	// Multi-line input, e.g. from a file, is shown as the line the error is on
+	lineStart := strings.LastIndex(e.expr[:e.offset], "\n") + 1
+	lineEnd := strings.Index(e.expr[e.offset:], "\n")
+	if lineEnd == -1 {
+		lineEnd = len(e.expr)
+	} else {
+		lineEnd += e.offset
+	}
+
+	if lineStart > 0 || lineEnd < len(e.expr) {
+		msg += fmt.Sprintf(" (line %d)", strings.Count(e.expr[:lineStart], "\n")+1)
+	}
+
+	line := strings.TrimRight(e.expr[lineStart:lineEnd], "\r")
+	column := utf8.RuneCountInString(e.expr[lineStart:e.offset])
+	return msg + "\n  " + line + "\n  " + strings.Repeat(" ", column) + "^"
+}
+
+// Sets the name of the flag whose value could not be parsed
+func withFlag(err error, flag string) error {
+	var parseErr *parseError
+	if errors.As(err, &parseErr) {
+		parseErr.flag = flag
+	}
+
+	return err
+}
+
+func isParseError(err error) bool {
+	var parseErr *parseError
+	return errors.As(err, &parseErr)
+}
+
+// An error returned by DynamoDB. input is the request which caused it.
+type awsError struct {
+	code    string
+	message string
+	input   string
+}
+
+func (e *awsError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// Requests which were throttled by DynamoDB, after the SDK has given up retrying them
+type throttlingError struct {
+	awsError
+}
+
+func (e *throttlingError) Error() string {
+	return "Request throttled, try again later (" + e.awsError.Error() + ")"
+}
+
+func (e *throttlingError) Unwrap() error {
+	return &e.awsError
+}
+
+// Keys or items which DynamoDB still left unprocessed after retrying them, which happens when the
+// table's capacity is exceeded
+func newUnprocessedError(code string, input string, format string, args ...interface{}) error {
+	return &throttlingError{awsError: awsError{code: code, message: fmt.Sprintf(format, args...), input: input}}
+}
+
+var throttlingCodes []string = []string{
+	"ProvisionedThroughputExceededException",
+	"ThrottlingException",
+	"RequestLimitExceeded",
+}
+
+// Wraps an error from the AWS SDK, keeping its error code
+func newAwsError(err error, input string) error {
+	var sdkErr awserr.Error
+	if !errors.As(err, &sdkErr) {
+		return &awsError{code: "Error", message: err.Error(), input: input}
+	}
+
+	wrapped := awsError{code: sdkErr.Code(), message: sdkErr.Message(), input: input}
+
+	if contains(throttlingCodes, sdkErr.Code()) {
+		return &throttlingError{awsError: wrapped}
+	}
+
+	return &wrapped
+}
+
+// Adds to the message of an error returned by DynamoDB, e.g. to say how much of a batch was written
+// before it, keeping the error's type. Other errors are returned as they are.
+func appendToMessage(err error, format string, args ...interface{}) error {
+	var awsErr *awsError
+	if errors.As(err, &awsErr) {
+		awsErr.message += fmt.Sprintf(format, args...)
+	}
+
+	return err
+}
+
+func exitCode(err error) int {
+	var validationErr *validationError
+	var parseErr *parseError
+	var awsErr *awsError
+	var throttlingErr *throttlingError
+
+	switch {
+	case err == nil:
+		return exitOk
+	case errors.As(err, &validationErr):
+		return exitValidation
+	case errors.As(err, &parseErr):
+		return exitParse
+	case errors.As(err, &throttlingErr):
+		return exitThrottled
+	case errors.As(err, &awsErr):
+		return exitAws
+	default:
+		return exitError
+	}
+}
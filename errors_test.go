@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_errors_exitCodes(t *testing.T) {
+	require.Equal(t, exitOk, exitCode(nil))
+	require.Equal(t, exitError, exitCode(errors.New("unexpected")))
+	require.Equal(t, exitValidation, exitCode(newValidationError("No table selected!")))
+	require.Equal(t, exitParse, exitCode(newParseError("Unterminated string")))
+	require.Equal(t, exitAws, exitCode(newAwsError(awserr.New("ResourceNotFoundException", "Not found", nil), "")))
+	require.Equal(t, exitThrottled, exitCode(newAwsError(awserr.New("ProvisionedThroughputExceededException", "Slow down", nil), "")))
+}
+
+func Test_errors_awsErrorCode(t *testing.T) {
+	err := newAwsError(awserr.New("ConditionalCheckFailedException", "The conditional request failed", nil), "input")
+
+	var awsErr *awsError
+	require.True(t, errors.As(err, &awsErr))
+	require.Equal(t, "ConditionalCheckFailedException", awsErr.code)
+	require.Equal(t, "input", awsErr.input)
+	require.Equal(t, "ConditionalCheckFailedException: The conditional request failed", err.Error())
+}
+
+func Test_errors_multiLinePosition(t *testing.T) {
+	_, err := parseValue("[\n  { pk: 'a' },\n  { pk: 'b }\n]")
+
+	require.Equal(t, "Unterminated string (line 3)\n"+
+		"    { pk: 'b }\n"+
+		"          ^", err.Error())
+}
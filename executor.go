@@ -2,13 +2,16 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
 	"runtime/debug"
+	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/bradfitz/slice"
 	"github.com/jessevdk/go-flags"
@@ -17,11 +20,33 @@ import (
 type executor struct {
 	dynamo   *dynamodb.DynamoDB
 	tableCtx *tableContext
+	state    *sessionState
 	verbose  bool
+	aliases  map[string]string
 }
 
-func newExecutor(dynamo *dynamodb.DynamoDB, tableCtx *tableContext, verbose bool) executor {
-	return executor{dynamo: dynamo, tableCtx: tableCtx, verbose: verbose}
+// State kept between commands
+type sessionState struct {
+	output      string
+	binary      string // encoding binary values are displayed in
+	sample      int64  // number of items read on "use" to learn the table's attributes
+	lastRead    *pagedRead
+	transaction *transaction // set between begin and commit/rollback
+	history     *history     // only kept in the interactive shell
+}
+
+// The last query, scan or PartiQL statement, kept so that it can be continued with "next"
+type pagedRead struct {
+	query            *dynamodb.QueryInput
+	scan             *dynamodb.ScanInput
+	statement        *dynamodb.ExecuteStatementInput
+	limit            *int64
+	lastEvaluatedKey map[string]*dynamodb.AttributeValue
+	nextToken        *string // statements are continued by NextToken instead of LastEvaluatedKey
+}
+
+func newExecutor(dynamo *dynamodb.DynamoDB, tableCtx *tableContext, output string, verbose bool) executor {
+	return executor{dynamo: dynamo, tableCtx: tableCtx, state: &sessionState{output: output, binary: binaryBase64}, verbose: verbose}
 }
 
 type readOpts struct {
@@ -32,8 +57,7 @@ type readOpts struct {
 	ConsumedCapacity string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
 	Select           string `short:"s" long:"select" description:"Select" required:"false"`
 	Limit            *int64 `short:"l" long:"limit" description:"Maximum items returned, equivalent to --max-items" required:"false"`
-	//TODO
-	//StartingToken    string `short:"t" long:"starting-token" description:"Starting token" required:"false"`
+	StartingToken    string `short:"t" long:"starting-token" description:"Token from a previous read to continue from" required:"false"`
 }
 
 type queryOpts struct {
@@ -48,6 +72,29 @@ type scanOpts struct {
 	Segment       *int64 `long:"segment" description:"Segment" required:"false"`
 }
 
+type getOpts struct {
+	Key              string `short:"k" long:"key" description:"Key as a map" required:"true"`
+	Projection       string `short:"p" long:"projection" description:"Projection expression" required:"false"`
+	ConsistentRead   bool   `short:"c" long:"consistent-read" description:"Set consistent-read to true" required:"false"`
+	ConsumedCapacity string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
+}
+
+type batchGetOpts struct {
+	Keys             string `short:"k" long:"keys" description:"List of keys, or a map of table names to lists of keys" required:"false"`
+	File             string `long:"file" description:"File containing keys, in the same format as --keys or one key per line" required:"false"`
+	Projection       string `short:"p" long:"projection" description:"Projection expression" required:"false"`
+	ConsistentRead   bool   `short:"c" long:"consistent-read" description:"Set consistent-read to true" required:"false"`
+	ConsumedCapacity string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
+}
+
+type batchWriteOpts struct {
+	Put              string `short:"p" long:"put" description:"List of items to put, or a map of table names to lists of items" required:"false"`
+	Delete           string `short:"d" long:"delete" description:"List of keys to delete, or a map of table names to lists of keys" required:"false"`
+	PutFile          string `long:"put-file" description:"File of items to put, in the same format as --put or one item per line" required:"false"`
+	DeleteFile       string `long:"delete-file" description:"File of keys to delete, in the same format as --delete or one key per line" required:"false"`
+	ConsumedCapacity string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
+}
+
 type writeOpts struct {
 	ConsumedCapacity            string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
 	ConditionExpression         string `short:"c" long:"condition-expression" description:"Condition expression" required:"false"`
@@ -72,117 +119,260 @@ type putOpts struct {
 }
 
 func (e executor) execute(input string) {
+	if err := e.run(input); err != nil {
+		e.printError(err)
+	}
+}
+
+// Runs a single command. Any unexpected panics are recovered and returned as errors.
+func (e executor) run(input string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println(r)
+			err = fmt.Errorf("Unexpected error: %v", r)
 			if e.verbose {
 				fmt.Println(string(debug.Stack()))
 			}
 		}
 	}()
 
-	e.handleInput(input)
+	return e.handleInput(expandAlias(e.aliases, input))
+}
+
+func (e executor) printError(err error) {
+	fmt.Println(err)
+
+	var awsErr *awsError
+	if errors.As(err, &awsErr) && !e.verbose {
+		fmt.Println("DEBUG input: \n" + awsErr.input)
+	}
+}
+
+// The commands handleInput runs, which are also suggested by the completer
+var commands []string = []string{"exit", "set", "use", "tables", "refresh", "history", "create-table", "update-table", "delete-table", "desc", "get", "batch-get", "query", "scan", "next", "delete", "update", "put", "batch-write", "transact-get", "sql", "partiql", "begin", "check", "commit", "rollback"}
+
+// Other names handleInput accepts for commands, which aren't suggested
+var commandSynonyms = map[string]string{"q": "exit", "quit": "exit", "more": "next"}
+
+// Whether handleInput runs a command called name
+func isCommand(name string) bool {
+	_, isSynonym := commandSynonyms[name]
+
+	return isSynonym || contains(commands, name)
 }
 
-func (e executor) handleInput(input string) {
+func (e executor) handleInput(input string) error {
 	firstSeparatorIdx := strings.Index(input, " ")
 
 	var command string = input
+	var args string
 	if firstSeparatorIdx != -1 {
 		command = input[:firstSeparatorIdx]
+		args = input[firstSeparatorIdx+1:]
+	}
+	if synonym, ok := commandSynonyms[command]; ok {
+		command = synonym
 	}
-	args := input[firstSeparatorIdx+1:]
 
 	switch command {
 	case "":
-		return
-	case "quit":
-		fallthrough
-	case "q":
-		fallthrough
+		return nil
 	case "exit":
 		fmt.Println("Goodbye")
 		os.Exit(0)
+		return nil
+	case "set":
+		return e.handleSet(args)
 	case "use":
-		e.handleUse(args)
+		return e.handleUse(args)
+	case "tables":
+		return e.handleTables(args)
+	case "refresh":
+		return e.handleRefresh()
+	case "history":
+		return e.handleHistory(args)
+	case "create-table":
+		return e.handleCreateTable(args)
+	case "update-table":
+		return e.handleUpdateTable(args)
+	case "delete-table":
+		return e.handleDeleteTable(args)
 	case "desc":
-		e.handleDesc()
+		return e.handleDesc(args)
+	case "get":
+		return e.handleGet(args)
+	case "batch-get":
+		return e.handleBatchGet(args)
 	case "query":
-		e.handleQuery(args)
+		return e.handleQuery(args)
 	case "scan":
-		e.handleScan(args)
+		return e.handleScan(args)
+	case "next":
+		return e.handleNext()
 	case "delete":
-		e.handleDelete(args)
+		return e.handleDelete(args)
 	case "update":
-		e.handleUpdate(args)
+		return e.handleUpdate(args)
 	case "put":
-		e.handlePut(args)
+		return e.handlePut(args)
+	case "batch-write":
+		return e.handleBatchWrite(args)
+	case "transact-get":
+		return e.handleTransactGet(args)
+	case "sql":
+		fallthrough
+	case "partiql":
+		return e.handleSql(args)
+	case "begin":
+		return e.handleBegin()
+	case "check":
+		return e.handleCheck(args)
+	case "commit":
+		return e.handleCommit(args)
+	case "rollback":
+		return e.handleRollback()
 	default:
-		fmt.Println("Unknown command: " + command)
+		return newValidationError("Unknown command: %s", command)
 	}
 }
 
-func (e executor) handleUse(tableName string) {
-	output, err := e.dynamo.DescribeTable(&dynamodb.DescribeTableInput{
-		TableName: &tableName,
-	})
+// Changes session settings, e.g. "set output json" or "set binary hex". Without arguments, prints the
+// current settings.
+func (e executor) handleSet(args string) error {
+	words := strings.Fields(args)
 
-	if err != nil {
-		panic(err)
+	if len(words) == 0 {
+		fmt.Println("output: " + e.state.output)
+		fmt.Println("binary: " + e.state.binary)
+		fmt.Printf("sample: %d\n", e.state.sample)
+		return nil
 	}
 
-	e.tableCtx.name = *output.Table.TableName
+	if len(words) != 2 {
+		return newValidationError("Usage: set <setting> <value>")
+	}
 
-	for _, s := range output.Table.KeySchema {
-		if *s.KeyType == "HASH" {
-			e.tableCtx.hashAttribute = *s.AttributeName
+	switch words[0] {
+	case "output":
+		if !contains(outputFormats, words[1]) {
+			return newValidationError("Unknown output format: %s, expected one of: %s", words[1], strings.Join(outputFormats, ", "))
 		}
-		if *s.KeyType == "RANGE" {
-			e.tableCtx.rangeAttribute = *s.AttributeName
+		e.state.output = words[1]
+	case "binary":
+		if !contains(binaryEncodings, words[1]) {
+			return newValidationError("Unknown binary encoding: %s, expected one of: %s", words[1], strings.Join(binaryEncodings, ", "))
 		}
+		e.state.binary = words[1]
+	case "sample":
+		sample, err := strconv.ParseInt(words[1], 10, 64)
+		if err != nil || sample < 0 {
+			return newValidationError("Sample must be a number of items, or 0 to disable sampling")
+		}
+		e.state.sample = sample
+	default:
+		return newValidationError("Unknown setting: %s", words[0])
 	}
 
-	indexNames := []string{}
-	for _, gsi := range output.Table.GlobalSecondaryIndexes {
-		indexNames = append(indexNames, *gsi.IndexName)
+	return nil
+}
+
+func (e executor) handleUse(tableName string) error {
+	describeInput := dynamodb.DescribeTableInput{
+		TableName: &tableName,
 	}
-	for _, lsi := range output.Table.LocalSecondaryIndexes {
-		indexNames = append(indexNames, *lsi.IndexName)
+
+	output, err := e.dynamo.DescribeTable(&describeInput)
+	if err != nil {
+		return newAwsError(err, describeInput.String())
 	}
 
-	e.tableCtx.indexes = indexNames
+	e.tableCtx.setTable(output.Table)
+	e.state.lastRead = nil
+	e.sampleAttributes()
+
+	return nil
 }
 
-func (e executor) handleDesc() {
-	e.validateTableSelected()
+// Sets the name, keys and indexes of the current table from its description
+func (ctx *tableContext) setTable(table *dynamodb.TableDescription) {
+	ctx.name = *table.TableName
 
-	describeInput := dynamodb.DescribeTableInput{
-		TableName: &e.tableCtx.name,
+	tableKeys := toKeySchema(table.KeySchema)
+	ctx.hashAttribute = tableKeys.hashAttribute
+	ctx.rangeAttribute = tableKeys.rangeAttribute
+
+	ctx.indexes = []string{}
+	ctx.indexKeys = map[string]keySchema{}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if aws.StringValue(gsi.IndexStatus) == dynamodb.IndexStatusDeleting {
+			continue
+		}
+		ctx.indexes = append(ctx.indexes, *gsi.IndexName)
+		ctx.indexKeys[*gsi.IndexName] = toKeySchema(gsi.KeySchema)
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		ctx.indexes = append(ctx.indexes, *lsi.IndexName)
+		ctx.indexKeys[*lsi.IndexName] = toKeySchema(lsi.KeySchema)
 	}
 
-	describeOutput, err := e.dynamo.DescribeTable(&describeInput)
-	if err == nil {
-		fmt.Println(describeOutput)
-	} else {
-		panic(err)
+	ctx.attributes = attributeCatalogue{}
+	ctx.attributeTypes = map[string]string{}
+	for _, definition := range table.AttributeDefinitions {
+		ctx.attributeTypes[*definition.AttributeName] = *definition.AttributeType
 	}
 }
 
-func (e executor) handleQuery(args string) {
-	e.validateTableSelected()
+// The key attributes of the table, or of one of its indexes when index isn't empty
+func (ctx *tableContext) keys(index string) keySchema {
+	if keys, ok := ctx.indexKeys[index]; ok {
+		return keys
+	}
+
+	return keySchema{hashAttribute: ctx.hashAttribute, rangeAttribute: ctx.rangeAttribute}
+}
+
+func toKeySchema(elements []*dynamodb.KeySchemaElement) keySchema {
+	keys := keySchema{}
+	for _, element := range elements {
+		if *element.KeyType == dynamodb.KeyTypeHash {
+			keys.hashAttribute = *element.AttributeName
+		}
+		if *element.KeyType == dynamodb.KeyTypeRange {
+			keys.rangeAttribute = *element.AttributeName
+		}
+	}
+
+	return keys
+}
+
+func (e executor) handleQuery(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
 
 	queryOpts := queryOpts{}
 
-	_, err := flags.ParseArgs(&queryOpts, parseArgs(args))
-	if err != nil {
-		return
+	if proceed, err := parseFlags(&queryOpts, args); !proceed {
+		return err
+	}
+
+	if err := validateLimit(queryOpts.Limit); err != nil {
+		return err
 	}
 
 	exprParser := newExprParser()
 
-	key := exprParser.parseGenericExpression(queryOpts.Key)
-	filter := exprParser.parseGenericExpression(queryOpts.Filter)
-	proj := exprParser.parseProjectionExpression(queryOpts.Projection)
+	key, err := exprParser.parseConditionExpression(queryOpts.Key)
+	if err != nil {
+		return withFlag(err, "--key")
+	}
+	filter, err := exprParser.parseConditionExpression(queryOpts.Filter)
+	if err != nil {
+		return withFlag(err, "--filter")
+	}
+	proj, err := exprParser.parseProjectionExpression(queryOpts.Projection)
+	if err != nil {
+		return withFlag(err, "--projection")
+	}
 
 	queryInput := dynamodb.QueryInput{
 		TableName:                 &e.tableCtx.name,
@@ -205,39 +395,69 @@ func (e executor) handleQuery(args string) {
 	if queryOpts.Select != "" {
 		queryInput.SetSelect(queryOpts.Select)
 	}
-	if queryOpts.Limit != nil {
-		queryInput.SetLimit(*queryOpts.Limit)
+	if queryOpts.StartingToken != "" {
+		startKey, err := decodeStartingToken(queryOpts.StartingToken)
+		if err != nil {
+			return err
+		}
+		queryInput.SetExclusiveStartKey(startKey)
 	}
 	if queryOpts.NoScanIndexForward {
 		queryInput.SetScanIndexForward(false)
 	}
 
+	return e.runQuery(&queryInput, queryOpts.Limit)
+}
+
+func (e executor) runQuery(queryInput *dynamodb.QueryInput, limit *int64) error {
 	if e.verbose {
 		fmt.Printf("DEBUG input: %v\n", queryInput)
 	}
 
-	queryOutput, err := e.dynamo.Query(&queryInput)
-	if err == nil {
-		fmt.Println(prettify(queryOutput))
-	} else {
-		e.handleDynamoError(err, queryInput.String())
+	queryOutput, err := queryPages(e.dynamo, queryInput, limit)
+	if err != nil {
+		return newAwsError(err, queryInput.String())
+	}
+
+	lastEvaluatedKey := queryOutput.LastEvaluatedKey
+	queryOutput.LastEvaluatedKey = nil
+
+	e.state.lastRead = &pagedRead{query: queryInput, limit: limit, lastEvaluatedKey: lastEvaluatedKey}
+
+	e.learnAttributes(queryInput.TableName, queryOutput.Items)
+
+	if err := e.printOutput(queryOutput); err != nil {
+		return err
 	}
+
+	return e.printNextToken(lastEvaluatedKey)
 }
 
-func (e executor) handleScan(args string) {
-	e.validateTableSelected()
+func (e executor) handleScan(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
 
 	scanOpts := scanOpts{}
 
-	_, err := flags.ParseArgs(&scanOpts, parseArgs(args))
-	if err != nil {
-		return
+	if proceed, err := parseFlags(&scanOpts, args); !proceed {
+		return err
+	}
+
+	if err := validateLimit(scanOpts.Limit); err != nil {
+		return err
 	}
 
 	exprParser := newExprParser()
 
-	filter := exprParser.parseGenericExpression(scanOpts.Filter)
-	proj := exprParser.parseProjectionExpression(scanOpts.Projection)
+	filter, err := exprParser.parseConditionExpression(scanOpts.Filter)
+	if err != nil {
+		return withFlag(err, "--filter")
+	}
+	proj, err := exprParser.parseProjectionExpression(scanOpts.Projection)
+	if err != nil {
+		return withFlag(err, "--projection")
+	}
 
 	scanInput := dynamodb.ScanInput{
 		TableName:                 &e.tableCtx.name,
@@ -259,8 +479,12 @@ func (e executor) handleScan(args string) {
 	if scanOpts.Select != "" {
 		scanInput.SetSelect(scanOpts.Select)
 	}
-	if scanOpts.Limit != nil {
-		scanInput.SetLimit(*scanOpts.Limit)
+	if scanOpts.StartingToken != "" {
+		startKey, err := decodeStartingToken(scanOpts.StartingToken)
+		if err != nil {
+			return err
+		}
+		scanInput.SetExclusiveStartKey(startKey)
 	}
 	if scanOpts.Segment != nil {
 		scanInput.SetSegment(*scanOpts.Segment)
@@ -269,48 +493,243 @@ func (e executor) handleScan(args string) {
 		scanInput.SetTotalSegments(*scanOpts.TotalSegments)
 	}
 
+	return e.runScan(&scanInput, scanOpts.Limit)
+}
+
+func (e executor) runScan(scanInput *dynamodb.ScanInput, limit *int64) error {
 	if e.verbose {
 		fmt.Printf("DEBUG input: %v\n", scanInput)
 	}
 
-	scanOutput, err := e.dynamo.Scan(&scanInput)
-	if err == nil {
-		fmt.Println(prettify(scanOutput))
+	scanOutput, err := scanPages(e.dynamo, scanInput, limit)
+	if err != nil {
+		return newAwsError(err, scanInput.String())
+	}
+
+	lastEvaluatedKey := scanOutput.LastEvaluatedKey
+	scanOutput.LastEvaluatedKey = nil
+
+	e.state.lastRead = &pagedRead{scan: scanInput, limit: limit, lastEvaluatedKey: lastEvaluatedKey}
+
+	e.learnAttributes(scanInput.TableName, scanOutput.Items)
+
+	if err := e.printOutput(scanOutput); err != nil {
+		return err
+	}
+
+	return e.printNextToken(lastEvaluatedKey)
+}
+
+// Continues the last query or scan from where it stopped
+func (e executor) handleNext() error {
+	lastRead := e.state.lastRead
+	if lastRead == nil || (lastRead.lastEvaluatedKey == nil && lastRead.nextToken == nil) {
+		return newValidationError("No more results to read")
+	}
+
+	if lastRead.statement != nil {
+		lastRead.statement.SetNextToken(*lastRead.nextToken)
+		return e.runStatement(lastRead.statement, lastRead.limit)
+	} else if lastRead.query != nil {
+		lastRead.query.SetExclusiveStartKey(lastRead.lastEvaluatedKey)
+		return e.runQuery(lastRead.query, lastRead.limit)
 	} else {
-		e.handleDynamoError(err, scanInput.String())
+		lastRead.scan.SetExclusiveStartKey(lastRead.lastEvaluatedKey)
+		return e.runScan(lastRead.scan, lastRead.limit)
 	}
 }
 
-func (e executor) handleDelete(args string) {
-	e.validateTableSelected()
+func (e executor) handleGet(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
+
+	getOpts := getOpts{}
 
-	deleteOpts := deleteOpts{}
+	if proceed, err := parseFlags(&getOpts, args); !proceed {
+		return err
+	}
 
-	_, err := flags.ParseArgs(&deleteOpts, parseArgs(args))
+	keyMap, err := parseItem(getOpts.Key)
 	if err != nil {
-		return
+		return withFlag(err, "--key")
+	}
+
+	getItemInput := dynamodb.GetItemInput{
+		TableName: &e.tableCtx.name,
+		Key:       keyMap,
+	}
+
+	if getOpts.Projection != "" {
+		exprParser := newExprParser()
+
+		proj, err := exprParser.parseProjectionExpression(getOpts.Projection)
+		if err != nil {
+			return withFlag(err, "--projection")
+		}
+
+		getItemInput.ProjectionExpression = proj
+		getItemInput.ExpressionAttributeNames = exprParser.getNames()
+	}
+
+	if getOpts.ConsistentRead {
+		getItemInput.SetConsistentRead(true)
 	}
 
-	keyMap, _, keyParseErr := tryParseMap(strings.Trim(deleteOpts.Key, " "))
-	if keyParseErr != nil {
-		panic(keyParseErr)
+	if getOpts.ConsumedCapacity != "" {
+		getItemInput.SetReturnConsumedCapacity(getOpts.ConsumedCapacity)
+	}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", getItemInput)
+	}
+
+	getOutput, err := e.dynamo.GetItem(&getItemInput)
+	if err != nil {
+		return newAwsError(err, getItemInput.String())
+	}
+
+	if getOutput.Item != nil {
+		e.learnAttributes(getItemInput.TableName, []map[string]*dynamodb.AttributeValue{getOutput.Item})
+	}
+
+	return e.printOutput(getOutput)
+}
+
+// Gets items by key from the current table, or several tables, in as many requests as needed
+func (e executor) handleBatchGet(args string) error {
+	batchGetOpts := batchGetOpts{}
+
+	if proceed, err := parseFlags(&batchGetOpts, args); !proceed {
+		return err
+	}
+
+	keys, err := e.readTableMaps(batchGetOpts.Keys, "--keys", batchGetOpts.File)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return newValidationError("No keys given, use --keys or --file")
+	}
+	keys = uniqueKeys(keys)
+
+	settings := dynamodb.KeysAndAttributes{}
+
+	if batchGetOpts.Projection != "" {
+		exprParser := newExprParser()
+
+		proj, err := exprParser.parseProjectionExpression(batchGetOpts.Projection)
+		if err != nil {
+			return withFlag(err, "--projection")
+		}
+
+		settings.ProjectionExpression = proj
+		settings.ExpressionAttributeNames = exprParser.getNames()
+	}
+
+	if batchGetOpts.ConsistentRead {
+		settings.SetConsistentRead(true)
+	}
+
+	output, err := batchGetItems(e.dynamo.BatchGetItem, keys, settings, batchGetOpts.ConsumedCapacity)
+	if err != nil {
+		return err
+	}
+
+	// Reads from a single table are shown as a list of items, like query and scan
+	isSingleTable := true
+	for _, key := range keys {
+		isSingleTable = isSingleTable && key.table == keys[0].table
+	}
+	if isSingleTable {
+		output.Items = output.Responses[keys[0].table]
+		if output.Items == nil {
+			output.Items = []map[string]*dynamodb.AttributeValue{}
+		}
+		output.Responses = nil
+	}
+
+	return e.printOutput(output)
+}
+
+// Reads batch keys or items from a flag, a file, or both. Returns an empty list when neither is set.
+func (e executor) readTableMaps(expr string, flag string, file string) ([]tableKey, error) {
+	result := []tableKey{}
+
+	if expr != "" {
+		maps, err := parseTableMaps(expr, e.tableCtx.name)
+		if err != nil {
+			return nil, withFlag(err, flag)
+		}
+		result = append(result, maps...)
+	}
+
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, newValidationError("Could not read file: %v", err)
+		}
+
+		maps, err := parseMapFile(string(content), e.tableCtx.name)
+		if err != nil {
+			return nil, withFlag(err, file)
+		}
+		result = append(result, maps...)
+	}
+
+	return result, nil
+}
+
+func (e executor) handleDelete(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
+
+	deleteOpts := deleteOpts{}
+
+	if proceed, err := parseFlags(&deleteOpts, args); !proceed {
+		return err
+	}
+
+	keyMap, err := parseItem(deleteOpts.Key)
+	if err != nil {
+		return withFlag(err, "--key")
 	}
 
 	deleteItemInput := dynamodb.DeleteItemInput{
-		TableName: &e.tableCtx.name,
-		Key:       keyMap.M,
+		TableName: aws.String(e.tableCtx.name),
+		Key:       keyMap,
 	}
 
 	if deleteOpts.ConditionExpression != "" {
 		exprParser := newExprParser()
 
-		condition := exprParser.parseGenericExpression(deleteOpts.ConditionExpression)
+		condition, err := exprParser.parseConditionExpression(deleteOpts.ConditionExpression)
+		if err != nil {
+			return withFlag(err, "--condition-expression")
+		}
 
 		deleteItemInput.ConditionExpression = condition
 		deleteItemInput.ExpressionAttributeNames = exprParser.getNames()
 		deleteItemInput.ExpressionAttributeValues = exprParser.getValues()
 	}
 
+	if e.state.transaction != nil {
+		returnValues, err := transactionReturnValues(deleteOpts.writeOpts)
+		if err != nil {
+			return err
+		}
+
+		return e.bufferWrite("delete "+args, &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName:                           deleteItemInput.TableName,
+			Key:                                 deleteItemInput.Key,
+			ConditionExpression:                 deleteItemInput.ConditionExpression,
+			ExpressionAttributeNames:            deleteItemInput.ExpressionAttributeNames,
+			ExpressionAttributeValues:           deleteItemInput.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: returnValues,
+		}})
+	}
+
 	if deleteOpts.ConsumedCapacity != "" {
 		deleteItemInput.SetReturnConsumedCapacity(deleteOpts.ConsumedCapacity)
 	}
@@ -328,42 +747,66 @@ func (e executor) handleDelete(args string) {
 	}
 
 	deleteOutput, err := e.dynamo.DeleteItem(&deleteItemInput)
-	if err == nil {
-		fmt.Println(prettify(deleteOutput))
-	} else {
-		e.handleDynamoError(err, deleteItemInput.String())
+	if err != nil {
+		return newAwsError(err, deleteItemInput.String())
 	}
+
+	return e.printOutput(deleteOutput)
 }
 
-func (e executor) handleUpdate(args string) {
-	e.validateTableSelected()
+func (e executor) handleUpdate(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
 
 	updateOpts := updateOpts{}
 
-	_, err := flags.ParseArgs(&updateOpts, parseArgs(args))
-	if err != nil {
-		return
+	if proceed, err := parseFlags(&updateOpts, args); !proceed {
+		return err
 	}
 
-	keyMap, _, keyParseErr := tryParseMap(strings.Trim(updateOpts.Key, " "))
-	if keyParseErr != nil {
-		panic(keyParseErr)
+	keyMap, err := parseItem(updateOpts.Key)
+	if err != nil {
+		return withFlag(err, "--key")
 	}
 
 	exprParser := newExprParser()
 
-	update := exprParser.parseGenericExpression(updateOpts.Update)
-	condition := exprParser.parseGenericExpression(updateOpts.ConditionExpression)
+	update, err := exprParser.parseUpdateExpression(updateOpts.Update)
+	if err != nil {
+		return withFlag(err, "--update")
+	}
+	condition, err := exprParser.parseConditionExpression(updateOpts.ConditionExpression)
+	if err != nil {
+		return withFlag(err, "--condition-expression")
+	}
 
 	updateItemInput := dynamodb.UpdateItemInput{
-		TableName:                 &e.tableCtx.name,
-		Key:                       keyMap.M,
+		TableName:                 aws.String(e.tableCtx.name),
+		Key:                       keyMap,
 		UpdateExpression:          update,
 		ConditionExpression:       condition,
 		ExpressionAttributeNames:  exprParser.getNames(),
 		ExpressionAttributeValues: exprParser.getValues(),
 	}
 
+	if e.state.transaction != nil {
+		returnValues, err := transactionReturnValues(updateOpts.writeOpts)
+		if err != nil {
+			return err
+		}
+
+		return e.bufferWrite("update "+args, &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                           updateItemInput.TableName,
+			Key:                                 updateItemInput.Key,
+			UpdateExpression:                    updateItemInput.UpdateExpression,
+			ConditionExpression:                 updateItemInput.ConditionExpression,
+			ExpressionAttributeNames:            updateItemInput.ExpressionAttributeNames,
+			ExpressionAttributeValues:           updateItemInput.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: returnValues,
+		}})
+	}
+
 	if updateOpts.ConsumedCapacity != "" {
 		updateItemInput.SetReturnConsumedCapacity(updateOpts.ConsumedCapacity)
 	}
@@ -381,40 +824,60 @@ func (e executor) handleUpdate(args string) {
 	}
 
 	updateOutput, err := e.dynamo.UpdateItem(&updateItemInput)
-	if err == nil {
-		fmt.Println(prettify(updateOutput))
-	} else {
-		e.handleDynamoError(err, updateItemInput.String())
+	if err != nil {
+		return newAwsError(err, updateItemInput.String())
 	}
+
+	return e.printOutput(updateOutput)
 }
 
-func (e executor) handlePut(args string) {
-	e.validateTableSelected()
+func (e executor) handlePut(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
 
 	putOpts := putOpts{}
 
-	_, err := flags.ParseArgs(&putOpts, parseArgs(args))
-	if err != nil {
-		return
+	if proceed, err := parseFlags(&putOpts, args); !proceed {
+		return err
 	}
 
-	item, _, itemParseErr := tryParseMap(strings.Trim(putOpts.Item, " "))
-	if itemParseErr != nil {
-		panic(itemParseErr)
+	item, err := parseItem(putOpts.Item)
+	if err != nil {
+		return withFlag(err, "--item")
 	}
 
 	exprParser := newExprParser()
 
-	condition := exprParser.parseGenericExpression(putOpts.ConditionExpression)
+	condition, err := exprParser.parseConditionExpression(putOpts.ConditionExpression)
+	if err != nil {
+		return withFlag(err, "--condition-expression")
+	}
 
 	putItemInput := dynamodb.PutItemInput{
-		TableName:                 &e.tableCtx.name,
-		Item:                      item.M,
+		TableName:                 aws.String(e.tableCtx.name),
+		Item:                      item,
 		ConditionExpression:       condition,
 		ExpressionAttributeNames:  exprParser.getNames(),
 		ExpressionAttributeValues: exprParser.getValues(),
 	}
 
+	if e.state.transaction != nil {
+		returnValues, err := transactionReturnValues(putOpts.writeOpts)
+		if err != nil {
+			return err
+		}
+
+		return e.bufferWrite("put "+args, &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+			TableName:                           putItemInput.TableName,
+			Item:                                putItemInput.Item,
+			ConditionExpression:                 putItemInput.ConditionExpression,
+			ExpressionAttributeNames:            putItemInput.ExpressionAttributeNames,
+			ExpressionAttributeValues:           putItemInput.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: returnValues,
+		}})
+	}
+
 	if putOpts.ConsumedCapacity != "" {
 		putItemInput.SetReturnConsumedCapacity(putOpts.ConsumedCapacity)
 	}
@@ -432,25 +895,144 @@ func (e executor) handlePut(args string) {
 	}
 
 	putOutput, err := e.dynamo.PutItem(&putItemInput)
-	if err == nil {
-		fmt.Println(prettify(putOutput))
-	} else {
-		e.handleDynamoError(err, putItemInput.String())
+	if err != nil {
+		return newAwsError(err, putItemInput.String())
 	}
+
+	return e.printOutput(putOutput)
+}
+
+// Puts and deletes items in the current table, or several tables, in as many requests as needed
+func (e executor) handleBatchWrite(args string) error {
+	batchWriteOpts := batchWriteOpts{}
+
+	if proceed, err := parseFlags(&batchWriteOpts, args); !proceed {
+		return err
+	}
+
+	puts, err := e.readTableMaps(batchWriteOpts.Put, "--put", batchWriteOpts.PutFile)
+	if err != nil {
+		return err
+	}
+	deletes, err := e.readTableMaps(batchWriteOpts.Delete, "--delete", batchWriteOpts.DeleteFile)
+	if err != nil {
+		return err
+	}
+
+	writes := []tableWrite{}
+	for _, put := range puts {
+		writes = append(writes, tableWrite{table: put.table, request: &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: put.key}}})
+	}
+	for _, del := range deletes {
+		writes = append(writes, tableWrite{table: del.table, request: &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: del.key}}})
+	}
+
+	if len(writes) == 0 {
+		return newValidationError("Nothing to write, use --put, --delete, --put-file or --delete-file")
+	}
+
+	keys := map[string]keySchema{}
+	for _, put := range puts {
+		if _, ok := keys[put.table]; !ok {
+			if keys[put.table], err = e.tableKeys(put.table); err != nil {
+				return err
+			}
+		}
+	}
+	if err := validateUniqueWrites(writes, keys, e.state.binary); err != nil {
+		return err
+	}
+
+	progress := func(written int, total int) {
+		if total > batchWriteSize {
+			e.printInfo(fmt.Sprintf("Written %d/%d", written, total))
+		}
+	}
+
+	output, err := batchWriteItems(e.dynamo.BatchWriteItem, writes, batchWriteOpts.ConsumedCapacity, progress)
+	if err != nil {
+		return err
+	}
+
+	return e.printOutput(output)
+}
+
+// The key schema of a table, which is only described when it isn't the current table
+func (e executor) tableKeys(table string) (keySchema, error) {
+	if table == e.tableCtx.name {
+		return e.tableCtx.keys(""), nil
+	}
+
+	describeInput := &dynamodb.DescribeTableInput{TableName: aws.String(table)}
+	describeOutput, err := e.dynamo.DescribeTable(describeInput)
+	if err != nil {
+		return keySchema{}, newAwsError(err, describeInput.String())
+	}
+
+	return toKeySchema(describeOutput.Table.KeySchema), nil
+}
+
+// Parses command flags into opts. proceed is false if the command shouldn't continue, either because
+// help was requested, or because the flags were invalid, in which case err is set as well.
+func parseFlags(opts interface{}, args string) (proceed bool, err error) {
+	_, proceed, err = parseFlagsAndArgs(opts, args)
+	return proceed, err
+}
+
+// Same as parseFlags, but also returns the arguments which aren't flags
+func parseFlagsAndArgs(opts interface{}, args string) (rest []string, proceed bool, err error) {
+	rest, err = flags.NewParser(opts, flags.HelpFlag|flags.PassDoubleDash).ParseArgs(parseArgs(args))
+
+	if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+		fmt.Println(err)
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, newValidationError("%s", err.Error())
+	}
+
+	return rest, true, nil
+}
+
+func validateLimit(limit *int64) error {
+	if limit != nil && *limit < 1 {
+		return newValidationError("Limit must be a positive number")
+	}
+
+	return nil
 }
 
-func (e executor) validateTableSelected() {
+func (e executor) printNextToken(lastEvaluatedKey map[string]*dynamodb.AttributeValue) error {
+	if lastEvaluatedKey == nil {
+		return nil
+	}
+
+	token, err := encodeStartingToken(lastEvaluatedKey)
+	if err != nil {
+		return err
+	}
+
+	e.printInfo("NextToken: " + token)
+
+	return nil
+}
+
+func (e executor) validateTableSelected() error {
 	if e.tableCtx.name == "" {
-		panic("No table selected!")
+		return newValidationError("No table selected!")
 	}
+
+	return nil
 }
 
-func (e executor) handleDynamoError(err error, cmdInput string) {
-	errOut := err.Error()
-	if !e.verbose {
-		errOut = errOut + "\n" + "DEBUG input: \n" + cmdInput
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	panic(errOut)
+
+	return false
 }
 
 // split args by ' ' and group quoted args
@@ -481,17 +1063,17 @@ func parseArgs(args string) []string {
 }
 
 // This is just awsutil.Prettify with a few modifications - displaying map entries in alphabetical order and
-// squishing simple value output to a single line.
+// displaying items and attribute values in the same syntax as they're written in (see formatValue).
 // Prettify returns the string representation of a value.
-func prettify(i interface{}) string {
+func prettify(i interface{}, binary string) string {
 	var buf bytes.Buffer
-	prettify0(reflect.ValueOf(i), 0, &buf)
+	prettify0(reflect.ValueOf(i), 0, binary, &buf)
 	return buf.String()
 }
 
 // prettify will recursively walk value v to build a textual
 // representation of the value.
-func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
+func prettify0(v reflect.Value, indent int, binary string, buf *bytes.Buffer) {
 	for v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
@@ -507,15 +1089,14 @@ func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
 			break
 		}
 
-		isAttributeValue := strtype == "dynamodb.AttributeValue"
-
-		buf.WriteString("{")
-		if isAttributeValue {
-			buf.WriteString(" ")
-		} else {
-			buf.WriteString("\n")
+		if strtype == "dynamodb.AttributeValue" {
+			value := v.Interface().(dynamodb.AttributeValue)
+			buf.WriteString(formatValue(&value, binary))
+			break
 		}
 
+		buf.WriteString("{\n")
+
 		names := []string{}
 		for i := 0; i < v.Type().NumField(); i++ {
 			name := v.Type().Field(i).Name
@@ -531,22 +1112,16 @@ func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
 
 		for i, n := range names {
 			val := v.FieldByName(n)
-			if !isAttributeValue {
-				buf.WriteString(strings.Repeat(" ", indent+2))
-			}
+			buf.WriteString(strings.Repeat(" ", indent+2))
 			buf.WriteString(n + ": ")
-			prettify0(val, indent+2, buf)
+			prettify0(val, indent+2, binary, buf)
 
 			if i < len(names)-1 {
 				buf.WriteString(",\n")
 			}
 		}
 
-		if isAttributeValue {
-			buf.WriteString(" }")
-		} else {
-			buf.WriteString("\n" + strings.Repeat(" ", indent) + "}")
-		}
+		buf.WriteString("\n" + strings.Repeat(" ", indent) + "}")
 	case reflect.Slice:
 		strtype := v.Type().String()
 		if strtype == "[]uint8" {
@@ -554,14 +1129,17 @@ func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
 			break
 		}
 
+		// items are always displayed one per line
+		isItemList := strtype == "[]map[string]*dynamodb.AttributeValue"
+
 		nl, id, id2 := "", "", ""
-		if v.Len() > 3 {
+		if v.Len() > 3 || (isItemList && v.Len() > 0) {
 			nl, id, id2 = "\n", strings.Repeat(" ", indent), strings.Repeat(" ", indent+2)
 		}
 		buf.WriteString("[" + nl)
 		for i := 0; i < v.Len(); i++ {
 			buf.WriteString(id2)
-			prettify0(v.Index(i), indent+2, buf)
+			prettify0(v.Index(i), indent+2, binary, buf)
 
 			if i < v.Len()-1 {
 				buf.WriteString("," + nl)
@@ -570,6 +1148,11 @@ func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
 
 		buf.WriteString(nl + id + "]")
 	case reflect.Map:
+		if item, isItem := v.Interface().(map[string]*dynamodb.AttributeValue); isItem {
+			buf.WriteString(formatItem(item, binary))
+			break
+		}
+
 		buf.WriteString("{\n")
 
 		keys := v.MapKeys()
@@ -581,7 +1164,7 @@ func prettify0(v reflect.Value, indent int, buf *bytes.Buffer) {
 		for i, k := range keys {
 			buf.WriteString(strings.Repeat(" ", indent+2))
 			buf.WriteString(k.String() + ": ")
-			prettify0(v.MapIndex(k), indent+2, buf)
+			prettify0(v.MapIndex(k), indent+2, binary, buf)
 
 			if i < v.Len()-1 {
 				buf.WriteString(",\n")
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+// A client which answers requests with the output returned by respond, instead of sending them.
+// respond gets the operation name, e.g. "Query", and the request's input.
+func mockDynamo(respond func(operation string, input interface{}) interface{}) *dynamodb.DynamoDB {
+	dynamo := dynamodb.New(session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})))
+
+	dynamo.Handlers.Send.Clear()
+	dynamo.Handlers.UnmarshalMeta.Clear()
+	dynamo.Handlers.Unmarshal.Clear()
+	dynamo.Handlers.ValidateResponse.Clear()
+	dynamo.Handlers.Send.PushBack(func(r *request.Request) {
+		output := respond(r.Operation.Name, r.Params)
+		reflect.ValueOf(r.Data).Elem().Set(reflect.ValueOf(output).Elem())
+	})
+
+	return dynamo
+}
+
+// Returns what run prints to stdout
+func captureStdout(t *testing.T, run func()) string {
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	run()
+
+	require.NoError(t, writer.Close())
+	out, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	return string(out)
+}
+
+func Test_tableContext_indexKeys(t *testing.T) {
+	// given
+	ctx := tableContext{}
+	ctx.setTable(&dynamodb.TableDescription{
+		TableName: aws.String("Orders"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("sk"), KeyType: aws.String("RANGE")},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("createdAt"), AttributeType: aws.String("N")},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName: aws.String("byCustomer"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("customerId"), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String("createdAt"), KeyType: aws.String("RANGE")},
+			},
+		}},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndexDescription{{
+			IndexName: aws.String("byStatus"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String("status"), KeyType: aws.String("RANGE")},
+			},
+		}},
+	})
+
+	// then
+	require.Equal(t, []string{"byCustomer", "byStatus"}, ctx.indexes)
+	require.Equal(t, keySchema{hashAttribute: "pk", rangeAttribute: "sk"}, ctx.keys(""))
+	require.Equal(t, keySchema{hashAttribute: "customerId", rangeAttribute: "createdAt"}, ctx.keys("byCustomer"))
+	require.Equal(t, keySchema{hashAttribute: "pk", rangeAttribute: "status"}, ctx.keys("byStatus"))
+	require.Equal(t, keySchema{hashAttribute: "pk", rangeAttribute: "sk"}, ctx.keys("unknown"))
+	require.Equal(t, "N", ctx.attributeTypes["createdAt"])
+}
+
+func Test_executor_next_continuesQuery(t *testing.T) {
+	// given
+	pages := []*dynamodb.QueryOutput{
+		{Count: aws.Int64(1), ScannedCount: aws.Int64(1), Items: []map[string]*dynamodb.AttributeValue{{"pk": str("a"), "sk": integer(1)}},
+			LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"pk": str("a"), "sk": integer(1)}},
+		{Count: aws.Int64(1), ScannedCount: aws.Int64(1), Items: []map[string]*dynamodb.AttributeValue{{"pk": str("a"), "sk": integer(2)}}},
+	}
+	inputs := []dynamodb.QueryInput{}
+	dynamo := mockDynamo(func(operation string, input interface{}) interface{} {
+		inputs = append(inputs, *input.(*dynamodb.QueryInput))
+		return pages[len(inputs)-1]
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders", hashAttribute: "pk", rangeAttribute: "sk"}, outputJsonLines, false)
+
+	// when
+	var queryErr, nextErr, lastErr error
+	out := captureStdout(t, func() {
+		queryErr = e.run(`query -k "pk = 'a'" -l 1`)
+		nextErr = e.run("next")
+		lastErr = e.run("next")
+	})
+
+	// then
+	require.NoError(t, queryErr)
+	require.NoError(t, nextErr)
+	require.EqualError(t, lastErr, "No more results to read")
+	require.Len(t, inputs, 2)
+	require.Nil(t, inputs[0].ExclusiveStartKey)
+	require.Equal(t, pages[0].LastEvaluatedKey, inputs[1].ExclusiveStartKey)
+	require.Equal(t, inputs[0].KeyConditionExpression, inputs[1].KeyConditionExpression)
+	require.Equal(t, "{\"pk\":\"a\",\"sk\":1}\n{\"pk\":\"a\",\"sk\":2}\n", out)
+}
+
+func Test_executor_get_parsesKey(t *testing.T) {
+	// given
+	var input *dynamodb.GetItemInput
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		input = params.(*dynamodb.GetItemInput)
+		return &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{"pk": str("a"), "sk": integer(1)}}
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders", hashAttribute: "pk", rangeAttribute: "sk"}, outputJsonLines, false)
+
+	// when
+	var err error
+	out := captureStdout(t, func() {
+		err = e.run(`get -k "{ pk: 'a', sk: 1 }" -p "sk, status" -c`)
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "Orders", *input.TableName)
+	require.Equal(t, map[string]*dynamodb.AttributeValue{"pk": str("a"), "sk": integer(1)}, input.Key)
+	require.Equal(t, "#0,#1", *input.ProjectionExpression)
+	require.Equal(t, map[string]*string{"#0": aws.String("sk"), "#1": aws.String("status")}, input.ExpressionAttributeNames)
+	require.True(t, *input.ConsistentRead)
+	require.Equal(t, "{\"pk\":\"a\",\"sk\":1}\n", out)
+}
+
+func Test_executor_get_invalidKey(t *testing.T) {
+	// given
+	requests := 0
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		requests++
+		return &dynamodb.GetItemOutput{}
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders"}, outputJsonLines, false)
+
+	// when
+	err := e.run(`get -k "pk = 'a'"`)
+
+	// then
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--key")
+	require.Equal(t, exitParse, exitCode(err))
+	require.Zero(t, requests)
+}
+
+func Test_executor_learnsAttributesOfCurrentTable(t *testing.T) {
+	// given
+	dynamo := mockDynamo(func(operation string, input interface{}) interface{} {
+		switch operation {
+		case "Scan":
+			return &dynamodb.ScanOutput{Count: aws.Int64(1), ScannedCount: aws.Int64(1), Items: []map[string]*dynamodb.AttributeValue{{"pk": str("a"), "total": integer(1)}}}
+		case "GetItem":
+			return &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{"pk": str("b"), "status": str("open")}}
+		default:
+			return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{
+				"Customers": {{"id": integer(1), "email": str("x")}},
+			}}
+		}
+	})
+	tableCtx := &tableContext{name: "Orders", hashAttribute: "pk", attributes: attributeCatalogue{}}
+	e := newExecutor(dynamo, tableCtx, outputJsonLines, false)
+
+	// when
+	var errs []error
+	captureStdout(t, func() {
+		errs = append(errs, e.run("scan"))
+		errs = append(errs, e.run(`get -k "{ pk: 'b' }"`))
+		errs = append(errs, e.run(`batch-get -k "{ Customers: [{ id: 1 }] }"`))
+	})
+
+	// then
+	require.Equal(t, []error{nil, nil, nil}, errs)
+	require.Equal(t, []string{"pk", "status", "total"}, tableCtx.attributes.matching(""))
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Nodes of a parsed expression. Rendering replaces names and values with placeholders, keeping the
+// rest of the expression, including its whitespace, as it was written.
+type exprNode interface {
+	render(p exprParserImpl, sb *strings.Builder)
+}
+
+// Keywords, operators and punctuation
+type tokenNode struct {
+	tok token
+}
+
+// e.g. a.b[1].`c d`
+type pathNode struct {
+	space    string
+	elements []pathElement
+}
+
+type pathElement struct {
+	name    string
+	indexes []string
+}
+
+type valueNode struct {
+	space string
+	value *dynamodb.AttributeValue
+}
+
+// e.g. begins_with(a, 'b')
+type functionNode struct {
+	name   token
+	open   token
+	args   []exprNode
+	commas []token
+	close  token
+}
+
+// e.g. a = 1, a <> b
+type comparisonNode struct {
+	left  exprNode
+	op    token
+	right exprNode
+}
+
+type betweenNode struct {
+	operand exprNode
+	between token
+	low     exprNode
+	and     token
+	high    exprNode
+}
+
+type inNode struct {
+	operand exprNode
+	in      token
+	open    token
+	values  []exprNode
+	commas  []token
+	close   token
+}
+
+// AND and OR
+type logicalNode struct {
+	left  exprNode
+	op    token
+	right exprNode
+}
+
+type notNode struct {
+	not     token
+	operand exprNode
+}
+
+type parenNode struct {
+	open  token
+	inner exprNode
+	close token
+}
+
+// A SET, REMOVE, ADD or DELETE clause of an update expression
+type updateClause struct {
+	keyword token
+	actions []exprNode
+	commas  []token
+}
+
+type updateNode struct {
+	clauses []updateClause
+}
+
+// SET path = value
+type setActionNode struct {
+	path  *pathNode
+	eq    token
+	value exprNode
+}
+
+// a + 1, a - b
+type arithmeticNode struct {
+	left  exprNode
+	op    token
+	right exprNode
+}
+
+// ADD path value, DELETE path value
+type pathValueActionNode struct {
+	path  *pathNode
+	value exprNode
+}
+
+// Projections are rendered without whitespace, e.g. "a, b" becomes "#0,#1"
+type projectionNode struct {
+	paths []*pathNode
+}
+
+func (n *tokenNode) render(p exprParserImpl, sb *strings.Builder) {
+	sb.WriteString(n.tok.space + n.tok.text)
+}
+
+// When names are nested, they need to be placeholdered separately.
+// When they're indexed, the index should be included raw (i.e. not in the placeholder value),
+// e.g. a.b[1].c becomes #0.#1[1].#2
+func (n *pathNode) render(p exprParserImpl, sb *strings.Builder) {
+	sb.WriteString(n.space)
+	n.renderPath(p, sb)
+}
+
+func (n *pathNode) renderPath(p exprParserImpl, sb *strings.Builder) {
+	for i, element := range n.elements {
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(p.addName(element.name))
+		for _, index := range element.indexes {
+			sb.WriteString("[" + index + "]")
+		}
+	}
+}
+
+func (n *valueNode) render(p exprParserImpl, sb *strings.Builder) {
+	sb.WriteString(n.space + p.addValue(n.value))
+}
+
+func (n *functionNode) render(p exprParserImpl, sb *strings.Builder) {
+	renderTokens(sb, n.name, n.open)
+	renderSeparated(p, sb, n.args, n.commas)
+	renderTokens(sb, n.close)
+}
+
+func (n *comparisonNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.left.render(p, sb)
+	renderTokens(sb, n.op)
+	n.right.render(p, sb)
+}
+
+func (n *betweenNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.operand.render(p, sb)
+	renderTokens(sb, n.between)
+	n.low.render(p, sb)
+	renderTokens(sb, n.and)
+	n.high.render(p, sb)
+}
+
+func (n *inNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.operand.render(p, sb)
+	renderTokens(sb, n.in, n.open)
+	renderSeparated(p, sb, n.values, n.commas)
+	renderTokens(sb, n.close)
+}
+
+func (n *logicalNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.left.render(p, sb)
+	renderTokens(sb, n.op)
+	n.right.render(p, sb)
+}
+
+func (n *notNode) render(p exprParserImpl, sb *strings.Builder) {
+	renderTokens(sb, n.not)
+	n.operand.render(p, sb)
+}
+
+func (n *parenNode) render(p exprParserImpl, sb *strings.Builder) {
+	renderTokens(sb, n.open)
+	n.inner.render(p, sb)
+	renderTokens(sb, n.close)
+}
+
+func (n *updateNode) render(p exprParserImpl, sb *strings.Builder) {
+	for _, clause := range n.clauses {
+		renderTokens(sb, clause.keyword)
+		renderSeparated(p, sb, clause.actions, clause.commas)
+	}
+}
+
+func (n *setActionNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.path.render(p, sb)
+	renderTokens(sb, n.eq)
+	n.value.render(p, sb)
+}
+
+func (n *arithmeticNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.left.render(p, sb)
+	renderTokens(sb, n.op)
+	n.right.render(p, sb)
+}
+
+func (n *pathValueActionNode) render(p exprParserImpl, sb *strings.Builder) {
+	n.path.render(p, sb)
+	n.value.render(p, sb)
+}
+
+func (n *projectionNode) render(p exprParserImpl, sb *strings.Builder) {
+	for i, path := range n.paths {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		path.renderPath(p, sb)
+	}
+}
+
+func renderTokens(sb *strings.Builder, tokens ...token) {
+	for _, tok := range tokens {
+		sb.WriteString(tok.space + tok.text)
+	}
+}
+
+// Renders nodes with the separators between them
+func renderSeparated(p exprParserImpl, sb *strings.Builder, nodes []exprNode, separators []token) {
+	for i, node := range nodes {
+		node.render(p, sb)
+		if i < len(separators) {
+			renderTokens(sb, separators[i])
+		}
+	}
+}
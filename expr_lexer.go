@@ -0,0 +1,180 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF         tokenKind = iota
+	tokenWord                  // names, keywords, function names, true/false/null
+	tokenNumber                // 123, 12.5, 1e10
+	tokenString                // 'string value'
+	tokenEscapedName           // `escaped name`
+	tokenBinary                // b64'SGVsbG8=' or x'48656c6c6f'
+	tokenSymbol                // operators and punctuation
+)
+
+// Two character symbols are listed first, so that they're matched before their prefixes
+var symbols []string = []string{"<=", ">=", "<>", "<<", ">>", "=", "<", ">", "(", ")", "[", "]", "{", "}", ",", ".", ":", "+", "-"}
+
+// Prefixes of binary values, which are otherwise written like strings
+var binaryPrefixes []string = []string{"b64", "x"}
+
+var rgxNumber = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?`)
+
+type token struct {
+	kind  tokenKind
+	text  string // the token as written
+	value string // unescaped value of strings and escaped names, same as text otherwise
+	pos   int    // byte offset of the token in the expression
+	space string // whitespace preceding the token
+}
+
+func (t token) end() int {
+	return t.pos + len(t.text)
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+// Keywords are case insensitive and only recognised as words, so a name such as "notes" is never
+// mistaken for the keyword "not"
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// Splits an expression into tokens. The last token is always tokenEOF, holding any trailing whitespace.
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+	pos := 0
+
+	for {
+		spaceStart := pos
+		for pos < len(expr) {
+			r, size := utf8.DecodeRuneInString(expr[pos:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			pos += size
+		}
+		space := expr[spaceStart:pos]
+
+		if pos == len(expr) {
+			return append(tokens, token{kind: tokenEOF, pos: pos, space: space}), nil
+		}
+
+		tok, err := nextToken(expr, pos)
+		if err != nil {
+			return nil, err
+		}
+
+		tok.space = space
+		tokens = append(tokens, tok)
+		pos = tok.end()
+	}
+}
+
+func nextToken(expr string, pos int) (token, error) {
+	rest := expr[pos:]
+	r, _ := utf8.DecodeRuneInString(rest)
+
+	switch {
+	case r == '\'':
+		return lexString(expr, pos)
+	case r == '`':
+		end := strings.Index(rest[1:], "`")
+		if end == -1 {
+			return token{}, newParseErrorAt(expr, pos, "Unterminated name")
+		}
+		return token{kind: tokenEscapedName, text: rest[:end+2], value: rest[1 : end+1], pos: pos}, nil
+	case binaryPrefix(rest) != "":
+		prefix := binaryPrefix(rest)
+		str, err := lexString(expr, pos+len(prefix))
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenBinary, text: prefix + str.text, value: str.value, pos: pos}, nil
+	case r >= '0' && r <= '9':
+		number := rgxNumber.FindString(rest)
+		// e.g. 1st is a name rather than a number followed by a name
+		if next, _ := utf8.DecodeRuneInString(rest[len(number):]); !isWordChar(next) {
+			return token{kind: tokenNumber, text: number, value: number, pos: pos}, nil
+		}
+		return lexWord(expr, pos), nil
+	case isWordChar(r):
+		return lexWord(expr, pos), nil
+	}
+
+	for _, symbol := range symbols {
+		if strings.HasPrefix(rest, symbol) {
+			return token{kind: tokenSymbol, text: symbol, value: symbol, pos: pos}, nil
+		}
+	}
+
+	return token{}, newParseErrorAt(expr, pos, "Unexpected character '%c'", r)
+}
+
+// Returns the prefix, as written, if rest starts with a binary value
+func binaryPrefix(rest string) string {
+	for _, prefix := range binaryPrefixes {
+		if len(rest) > len(prefix) && strings.EqualFold(rest[:len(prefix)], prefix) && rest[len(prefix)] == '\'' {
+			return rest[:len(prefix)]
+		}
+	}
+
+	return ""
+}
+
+// Words can contain hyphens between word characters, e.g. created-at, so that "a-b" is a name while
+// "a - b" is a subtraction
+func lexWord(expr string, pos int) token {
+	end := pos
+	for end < len(expr) {
+		r, size := utf8.DecodeRuneInString(expr[end:])
+		if r == '-' {
+			if next, _ := utf8.DecodeRuneInString(expr[end+size:]); !isWordChar(next) {
+				break
+			}
+		} else if !isWordChar(r) {
+			break
+		}
+		end += size
+	}
+
+	return token{kind: tokenWord, text: expr[pos:end], value: expr[pos:end], pos: pos}
+}
+
+// Strings are single quoted. Single quotes, double quotes and backslashes can be escaped with a backslash.
+func lexString(expr string, pos int) (token, error) {
+	var value strings.Builder
+
+	for i := pos + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\'':
+			return token{kind: tokenString, text: expr[pos : i+1], value: value.String(), pos: pos}, nil
+		case '\\':
+			if i+1 == len(expr) {
+				return token{}, newParseErrorAt(expr, pos, "Unterminated string")
+			}
+			if expr[i+1] != '\'' && expr[i+1] != '"' && expr[i+1] != '\\' {
+				return token{}, newParseErrorAt(expr, i, "Unexpected escape character")
+			}
+			i++
+			value.WriteByte(expr[i])
+		default:
+			value.WriteByte(expr[i])
+		}
+	}
+
+	return token{}, newParseErrorAt(expr, pos, "Unterminated string")
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
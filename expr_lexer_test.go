@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tokenize(t *testing.T) {
+	// when
+	tokens, err := tokenize(" a.`b c`[1] <= -12.5e3 AND 'it\\'s'  ")
+	require.NoError(t, err)
+
+	// then
+	expected := []token{
+		{kind: tokenWord, text: "a", value: "a", pos: 1, space: " "},
+		{kind: tokenSymbol, text: ".", value: ".", pos: 2},
+		{kind: tokenEscapedName, text: "`b c`", value: "b c", pos: 3},
+		{kind: tokenSymbol, text: "[", value: "[", pos: 8},
+		{kind: tokenNumber, text: "1", value: "1", pos: 9},
+		{kind: tokenSymbol, text: "]", value: "]", pos: 10},
+		{kind: tokenSymbol, text: "<=", value: "<=", pos: 12, space: " "},
+		{kind: tokenSymbol, text: "-", value: "-", pos: 15, space: " "},
+		{kind: tokenNumber, text: "12.5e3", value: "12.5e3", pos: 16},
+		{kind: tokenWord, text: "AND", value: "AND", pos: 23, space: " "},
+		{kind: tokenString, text: "'it\\'s'", value: "it's", pos: 27, space: " "},
+		{kind: tokenEOF, pos: 36, space: "  "},
+	}
+	require.Equal(t, expected, tokens)
+}
+
+func Test_tokenize_wordBoundaries(t *testing.T) {
+	// when
+	tokens, err := tokenize("notes 1st size_bytes")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, "notes", tokens[0].text)
+	require.Equal(t, tokenWord, tokens[1].kind)
+	require.Equal(t, "1st", tokens[1].text)
+	require.Equal(t, "size_bytes", tokens[2].text)
+	require.True(t, tokens[0].isKeyword("NOTES"))
+	require.False(t, tokens[0].isKeyword("not"))
+}
+
+func Test_tokenize_hyphens(t *testing.T) {
+	// when
+	tokens, err := tokenize("created-at - a-1 -b c--d")
+	require.NoError(t, err)
+
+	// then
+	texts := []string{}
+	for _, tok := range tokens[:len(tokens)-1] {
+		texts = append(texts, tok.text)
+	}
+	require.Equal(t, []string{"created-at", "-", "a-1", "-", "b", "c", "-", "-", "d"}, texts)
+}
@@ -1,32 +1,61 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
-// Don't include "<<", "[" in specials, as we want them left intact for set/list parsing
-var specials []string = []string{" ", ",", "(", ")", "]", ">>", "=", ">", "<", ">=", "<=", "<>"}
-var keywords []string = append(specials,
-	"and", "or", "not", "in", "between",
-	"set", "remove", "add", "delete", "+", "-",
-	"begins_with", "attribute_exists", "attribute_not_exists", "attribute_type", "contains", "size", "list_append", "if_not_exists",
+var conditionFunctions []string = []string{"attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains", "size"}
+var updateFunctions []string = []string{"if_not_exists", "list_append"}
+
+// Shown when completing function names
+var functionSignatures = map[string]string{
+	"attribute_exists":     "attribute_exists(path)",
+	"attribute_not_exists": "attribute_not_exists(path)",
+	"attribute_type":       "attribute_type(path, type)",
+	"begins_with":          "begins_with(path, substr)",
+	"contains":             "contains(path, operand)",
+	"size":                 "size(path)",
+	"if_not_exists":        "if_not_exists(path, value)",
+	"list_append":          "list_append(list1, list2)",
+}
+
+var updateClauses []string = []string{"SET", "REMOVE", "ADD", "DELETE"}
+
+// Type codes accepted by attribute_type, with their descriptions
+var attributeTypeCodes = [][2]string{
+	{"S", "String"}, {"SS", "String Set"}, {"N", "Number"}, {"NS", "Number Set"}, {"B", "Binary"},
+	{"BS", "Binary Set"}, {"BOOL", "Boolean"}, {"NULL", "Null"}, {"L", "List"}, {"M", "Map"},
+}
+var rgxDecimal = regexp.MustCompile(`^-?([0-9]+)(?:\.([0-9]+))?(?:[eE]([+-]?[0-9]+))?$`)
+
+const (
+	maxNumberDigits    = 38
+	minNumberMagnitude = -130
+	maxNumberMagnitude = 125
 )
 
+var comparators []string = []string{"=", "<>", "<", "<=", ">", ">="}
+
 type exprParser interface {
-	parseGenericExpression(expr string) *string
-	parseProjectionExpression(expr string) *string
+	parseGenericExpression(expr string) (*string, error)
+	parseConditionExpression(expr string) (*string, error)
+	parseUpdateExpression(expr string) (*string, error)
+	parseProjectionExpression(expr string) (*string, error)
 	getNames() map[string]*string
 	getValues() map[string]*dynamodb.AttributeValue
 }
 
 type exprParserImpl struct {
 	nameIdx, valueIdx *int
-	key, filter, proj *string
 	names             map[string]*string
 	values            map[string]*dynamodb.AttributeValue
 }
@@ -74,343 +103,748 @@ func (p exprParserImpl) addValue(value *dynamodb.AttributeValue) string {
 	return placeholder
 }
 
-// parses key, update, filter/condition expressions
-// updates parser's names and values
-func (p exprParserImpl) parseGenericExpression(expr string) (resultExpr *string) {
-	resultExpr = new(string)
+// Parses either an update or a key/filter/condition expression, depending on how it starts
+func (p exprParserImpl) parseGenericExpression(expr string) (*string, error) {
+	return p.parseExpression(expr, func(tp *tokenParser) (exprNode, error) {
+		if tp.isUpdateExpression() {
+			return tp.parseUpdate()
+		}
+		return tp.parseCondition()
+	})
+}
 
-	for len(expr) > 0 {
-		// Try to parse keyword
-		keyword := startsWithKeyword(expr)
+// Parses key, filter and condition expressions
+func (p exprParserImpl) parseConditionExpression(expr string) (*string, error) {
+	return p.parseExpression(expr, (*tokenParser).parseCondition)
+}
 
-		if keyword != nil {
-			*resultExpr = *resultExpr + *keyword
-			expr = expr[len(*keyword):]
-			continue
-		}
+func (p exprParserImpl) parseUpdateExpression(expr string) (*string, error) {
+	return p.parseExpression(expr, (*tokenParser).parseUpdate)
+}
 
-		// Try to parse value
-		value, remainder, _ := tryParseValue(expr)
-		if value != nil {
-			withValue := *resultExpr + p.addValue(value)
-			*resultExpr = withValue
-			expr = remainder
-			continue
-		}
+func (p exprParserImpl) parseProjectionExpression(expr string) (*string, error) {
+	return p.parseExpression(strings.TrimSpace(expr), (*tokenParser).parseProjection)
+}
 
-		// Default to name
-		resultName, remainder := p.parseName(expr)
-		withName := *resultExpr + resultName
-		*resultExpr = withName
-		expr = remainder
+// Parses expr with the given grammar, then renders it with placeholders, updating the parser's
+// names and values. Returns nil for empty expressions.
+func (p exprParserImpl) parseExpression(expr string, grammar func(*tokenParser) (exprNode, error)) (*string, error) {
+	tp, err := newTokenParser(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(*resultExpr) > 0 {
-		return resultExpr
-	} else {
-		return nil
+	if tp.atEnd() {
+		return nil, nil
 	}
-}
 
-func (p exprParserImpl) parseProjectionExpression(expr string) *string {
-	expr = strings.Trim(expr, " ")
+	root, err := grammar(tp)
+	if err != nil {
+		return nil, err
+	}
+	if !tp.atEnd() {
+		return nil, tp.errorAt(tp.peek(), "Unexpected input")
+	}
 
-	placeholderExpr := ""
+	var sb strings.Builder
+	root.render(p, &sb)
+	sb.WriteString(tp.peek().space)
 
-	for len(expr) > 0 {
-		name, remainder := p.parseName(expr)
-		expr = remainder
-		placeholderExpr = placeholderExpr + name
+	result := sb.String()
+	return &result, nil
+}
 
-		if len(expr) > 0 {
-			expr = strings.TrimLeft(expr[1:], " ")
-			placeholderExpr = placeholderExpr + ","
-		}
+// Parses an item or key, written as a map
+func parseItem(expr string) (map[string]*dynamodb.AttributeValue, error) {
+	tp, err := newTokenParser(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(placeholderExpr) > 0 {
-		return &placeholderExpr
-	} else {
-		return nil
+	if !tp.peek().isSymbol("{") {
+		return nil, tp.errorAt(tp.peek(), "Expected a map")
 	}
+
+	item, err := tp.parseMap()
+	if err != nil {
+		return nil, err
+	}
+	if !tp.atEnd() {
+		return nil, tp.errorAt(tp.peek(), "Unexpected input after map")
+	}
+
+	return item.M, nil
 }
 
-// When names are nested, they need to be placeholdered separately
-// When they're indexed, the index should be included raw (i.e. not in the placeholder value)
-// e.g. a.b[1].c needs to become #1.#2[1].#3
-func (p exprParserImpl) parseName(expr string) (resultName string, remainder string) {
-	isEscaped := false
-	if strings.HasPrefix(expr, "`") {
-		isEscaped = true
-		expr = expr[1:]
+// Parses items written one after another, e.g. one per line of a file
+func parseItems(expr string) ([]map[string]*dynamodb.AttributeValue, error) {
+	tp, err := newTokenParser(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	var parsedName string
-	if isEscaped { // if escaped, just read until closing tilde
-		indexEnd := strings.Index(expr, "`")
-		parsedName = expr[:indexEnd]
-		remainder = expr[indexEnd+1:]
-	} else {
-		parsedName, remainder = parseNextToken(expr, ".", "[")
+	items := []map[string]*dynamodb.AttributeValue{}
+	for !tp.atEnd() {
+		if !tp.peek().isSymbol("{") {
+			return nil, tp.errorAt(tp.peek(), "Expected a map")
+		}
+
+		item, err := tp.parseMap()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item.M)
 	}
 
-	resultName = p.addName(parsedName)
+	return items, nil
+}
 
-	if len(remainder) > 0 && remainder[0] == '[' {
-		closingIdx := findWithOffset(remainder, "]", 1)
-		resultName = resultName + remainder[:closingIdx+1]
-		remainder = remainder[closingIdx+1:]
+// Parses a single value, e.g. a list of keys
+func parseValue(expr string) (*dynamodb.AttributeValue, error) {
+	tp, err := newTokenParser(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(remainder) > 0 && remainder[0] == '.' {
-		parsedName, remainder = p.parseName(remainder[1:])
-		resultName = resultName + "." + parsedName
+	value, err := tp.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if !tp.atEnd() {
+		return nil, tp.errorAt(tp.peek(), "Unexpected input after value")
 	}
 
-	return resultName, remainder
+	return value, nil
 }
 
-// returns the next token, as terminated by either a special or the end of the string
-// the returned remainder starts with the termination string
-func parseNextToken(expr string, additionalDelimiters ...string) (attributeName string, remainder string) {
-	delimiterIndexes := []int{len(expr)}
+// Recursive descent parser over the tokens of an expression
+type tokenParser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
 
-	for _, s := range append(specials, additionalDelimiters...) {
-		idx := strings.Index(expr, string(s))
-		if idx != -1 {
-			delimiterIndexes = append(delimiterIndexes, idx)
-		}
+func newTokenParser(expr string) (*tokenParser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
 	}
 
-	smallest := delimiterIndexes[0]
+	return &tokenParser{expr: expr, tokens: tokens}, nil
+}
 
-	for _, num := range delimiterIndexes {
-		if num < smallest {
-			smallest = num
-		}
+func (tp *tokenParser) peek() token {
+	return tp.peekAt(0)
+}
+
+// The EOF token is returned for anything past the end
+func (tp *tokenParser) peekAt(offset int) token {
+	if tp.pos+offset >= len(tp.tokens) {
+		return tp.tokens[len(tp.tokens)-1]
 	}
 
-	attributeName = expr[:smallest]
-	expr = expr[smallest:]
+	return tp.tokens[tp.pos+offset]
+}
 
-	return attributeName, expr
+func (tp *tokenParser) next() token {
+	tok := tp.peek()
+	if tok.kind != tokenEOF {
+		tp.pos++
+	}
+
+	return tok
 }
 
-func tryParseValue(expr string) (*dynamodb.AttributeValue, string, error) {
-	var value *dynamodb.AttributeValue
+func (tp *tokenParser) atEnd() bool {
+	return tp.peek().kind == tokenEOF
+}
 
-	value, remainder, err := tryParseSimpleValue(expr)
-	if err != nil {
-		value, remainder, err = tryParseList(expr)
+func (tp *tokenParser) errorAt(tok token, format string, args ...interface{}) error {
+	return newParseErrorAt(tp.expr, tok.pos, format, args...)
+}
+
+func (tp *tokenParser) expectSymbol(symbol string) (token, error) {
+	tok := tp.peek()
+	if !tok.isSymbol(symbol) {
+		return tok, tp.errorAt(tok, "Expected '%s'", symbol)
 	}
+
+	return tp.next(), nil
+}
+
+// Update expressions start with a clause keyword followed by a name, e.g. "SET a = 1". A condition
+// may also start with one of those words when it's an attribute name, e.g. "set = 1".
+func (tp *tokenParser) isUpdateExpression() bool {
+	first, second := tp.peekAt(0), tp.peekAt(1)
+
+	isClause := first.isKeyword("set") || first.isKeyword("remove") || first.isKeyword("add") || first.isKeyword("delete")
+	return isClause && (second.kind == tokenWord || second.kind == tokenEscapedName)
+}
+
+// condition = and {OR and}
+func (tp *tokenParser) parseCondition() (exprNode, error) {
+	left, err := tp.parseAnd()
 	if err != nil {
-		value, remainder, err = tryParseMap(expr)
+		return nil, err
+	}
+
+	for tp.peek().isKeyword("or") {
+		op := tp.next()
+		right, err := tp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, op: op, right: right}
 	}
+
+	return left, nil
+}
+
+// and = not {AND not}
+func (tp *tokenParser) parseAnd() (exprNode, error) {
+	left, err := tp.parseNot()
 	if err != nil {
-		return nil, expr, errors.New("Could not parse value at: " + expr)
+		return nil, err
 	}
 
-	return value, remainder, nil
+	for tp.peek().isKeyword("and") {
+		op := tp.next()
+		right, err := tp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, op: op, right: right}
+	}
+
+	return left, nil
 }
 
-func tryParseSimpleValue(expr string) (*dynamodb.AttributeValue, string, error) {
-	var value interface{}
+// not = NOT not | comparison
+func (tp *tokenParser) parseNot() (exprNode, error) {
+	if !tp.peek().isKeyword("not") {
+		return tp.parseComparison()
+	}
 
-	value, remainder, err := tryParseString(expr)
+	not := tp.next()
+	operand, err := tp.parseNot()
 	if err != nil {
-		value, remainder, err = tryParseNumber(expr)
+		return nil, err
 	}
-	if err != nil {
-		value, remainder, err = tryParseBoolean(expr)
+
+	return &notNode{not: not, operand: operand}, nil
+}
+
+// comparison = "(" condition ")" | function | operand comparator operand
+// | operand BETWEEN operand AND operand | operand IN "(" operand {"," operand} ")"
+func (tp *tokenParser) parseComparison() (exprNode, error) {
+	if tp.peek().isSymbol("(") {
+		open := tp.next()
+		inner, err := tp.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		close, err := tp.expectSymbol(")")
+		if err != nil {
+			return nil, err
+		}
+		return &parenNode{open: open, inner: inner, close: close}, nil
 	}
+
+	operand, err := tp.parseOperand()
 	if err != nil {
-		remainder, err = tryParseNull(expr)
-		value = nil
+		return nil, err
 	}
-	if err != nil {
-		return nil, expr, err
+
+	tok := tp.peek()
+	switch {
+	case tok.kind == tokenSymbol && contains(comparators, tok.text):
+		op := tp.next()
+		right, err := tp.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonNode{left: operand, op: op, right: right}, nil
+	case tok.isKeyword("between"):
+		between := tp.next()
+		low, err := tp.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !tp.peek().isKeyword("and") {
+			return nil, tp.errorAt(tp.peek(), "Expected AND")
+		}
+		and := tp.next()
+		high, err := tp.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenNode{operand: operand, between: between, low: low, and: and, high: high}, nil
+	case tok.isKeyword("in"):
+		in := tp.next()
+		open, err := tp.expectSymbol("(")
+		if err != nil {
+			return nil, err
+		}
+		values, commas, err := tp.parseSeparated(tp.parseOperand)
+		if err != nil {
+			return nil, err
+		}
+		close, err := tp.expectSymbol(")")
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{operand: operand, in: in, open: open, values: values, commas: commas, close: close}, nil
 	}
 
-	attributeValue, err := dynamodbattribute.Marshal(value)
-	if err != nil {
-		panic(err)
+	if _, isFunction := operand.(*functionNode); isFunction {
+		return operand, nil
 	}
 
-	return attributeValue, remainder, nil
+	return nil, tp.errorAt(tok, "Expected a comparison")
 }
 
-func tryParseString(expr string) (parsedStr string, remainder string, err error) {
-	if expr[0] != '\'' {
-		return "", expr, errors.New("Expected string value at: " + expr)
-	}
+// operand = value | function | path
+func (tp *tokenParser) parseOperand() (exprNode, error) {
+	tok := tp.peek()
 
-	expr = expr[1:]
+	switch {
+	case tp.atValue():
+		space := tok.space
+		value, err := tp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &valueNode{space: space, value: value}, nil
+	case tok.kind == tokenWord && tp.peekAt(1).isSymbol("("):
+		return tp.parseFunction()
+	case tok.kind == tokenWord || tok.kind == tokenEscapedName:
+		return tp.parsePath()
+	default:
+		return nil, tp.errorAt(tok, "Expected a name or value")
+	}
+}
 
-	idxQuote := strings.Index(expr, "'")
-	if idxQuote == 0 {
-		panic("Unterminated string: " + expr)
+func (tp *tokenParser) parseFunction() (exprNode, error) {
+	name := tp.next()
+	if !contains(conditionFunctions, name.text) && !contains(updateFunctions, name.text) {
+		return nil, tp.errorAt(name, "Unknown function '%s'", name.text)
 	}
 
-	idxEscape := strings.Index(expr, "\\")
+	open := tp.next()
+	node := &functionNode{name: name, open: open}
 
-	for idxEscape > -1 && idxEscape < idxQuote {
-		if expr[idxEscape+1] == '"' || expr[idxEscape+1] == '\'' || expr[idxEscape+1] == '\\' {
-			expr = expr[:idxEscape] + expr[idxEscape+1:]
-			idxQuote = findWithOffset(expr, "'", idxEscape+1)
-			idxEscape = findWithOffset(expr, "\\", idxEscape+1)
-		} else {
-			panic("Unexpected escape character: " + expr)
+	if !tp.peek().isSymbol(")") {
+		args, commas, err := tp.parseSeparated(tp.parseOperand)
+		if err != nil {
+			return nil, err
 		}
+		node.args, node.commas = args, commas
 	}
 
-	str := expr[:idxQuote]
+	close, err := tp.expectSymbol(")")
+	if err != nil {
+		return nil, err
+	}
+	node.close = close
 
-	return str, expr[idxQuote+1:], nil
+	return node, nil
 }
 
-func findWithOffset(str string, search string, offset int) int {
-	idx := strings.Index(str[offset:], search)
+// path = name {"[" index "]"} {"." path}
+func (tp *tokenParser) parsePath() (*pathNode, error) {
+	node := &pathNode{space: tp.peek().space}
 
-	if idx == -1 {
-		return -1
-	} else {
-		return offset + idx
+	for {
+		tok := tp.next()
+		if tok.kind != tokenWord && tok.kind != tokenEscapedName {
+			return nil, tp.errorAt(tok, "Expected a name")
+		}
+
+		element := pathElement{name: tok.value}
+		for tp.peek().isSymbol("[") {
+			tp.next()
+			index := tp.next()
+			if _, err := strconv.Atoi(index.text); index.kind != tokenNumber || err != nil {
+				return nil, tp.errorAt(index, "Expected a list index")
+			}
+			if _, err := tp.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+			element.indexes = append(element.indexes, index.text)
+		}
+		node.elements = append(node.elements, element)
+
+		if !tp.peek().isSymbol(".") {
+			return node, nil
+		}
+		tp.next()
+	}
+}
+
+// update = clause {clause}, where a clause is SET, REMOVE, ADD or DELETE followed by comma separated
+// actions, e.g. "SET a = a + 1, b = 'x' REMOVE c"
+func (tp *tokenParser) parseUpdate() (exprNode, error) {
+	node := &updateNode{}
+
+	for !tp.atEnd() {
+		keyword := tp.next()
+
+		var parseAction func() (exprNode, error)
+		switch {
+		case keyword.isKeyword("set"):
+			parseAction = tp.parseSetAction
+		case keyword.isKeyword("remove"):
+			parseAction = func() (exprNode, error) { return tp.parsePath() }
+		case keyword.isKeyword("add") || keyword.isKeyword("delete"):
+			parseAction = tp.parsePathValueAction
+		default:
+			return nil, tp.errorAt(keyword, "Expected SET, REMOVE, ADD or DELETE")
+		}
+
+		actions, commas, err := tp.parseSeparated(parseAction)
+		if err != nil {
+			return nil, err
+		}
+		node.clauses = append(node.clauses, updateClause{keyword: keyword, actions: actions, commas: commas})
 	}
+
+	return node, nil
 }
 
-func tryParseNumber(expr string) (parsedNum interface{}, remainder string, err error) {
-	parsedNum, remainder, err = tryParseInt(expr)
-	if err == nil {
-		return parsedNum, remainder, nil
+// action = path "=" operand [("+" | "-") operand]
+func (tp *tokenParser) parseSetAction() (exprNode, error) {
+	path, err := tp.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	eq, err := tp.expectSymbol("=")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := tp.parseOperand()
+	if err != nil {
+		return nil, err
 	}
 
-	parsedNum, remainder, err = tryParseFloat(expr)
-	if err == nil {
-		return parsedNum, remainder, nil
+	if tp.peek().isSymbol("+") || tp.peek().isSymbol("-") {
+		op := tp.next()
+		right, err := tp.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		value = &arithmeticNode{left: value, op: op, right: right}
 	}
 
-	return nil, expr, errors.New("Expected number value at: " + expr)
+	return &setActionNode{path: path, eq: eq, value: value}, nil
 }
 
-func tryParseInt(expr string) (parsedInt int, remainder string, err error) {
-	num, expr := parseNextToken(expr)
-	parsedInt, parseErr := strconv.Atoi(num)
+func (tp *tokenParser) parsePathValueAction() (exprNode, error) {
+	path, err := tp.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	value, err := tp.parseOperand()
+	if err != nil {
+		return nil, err
+	}
 
-	return parsedInt, expr, parseErr
+	return &pathValueActionNode{path: path, value: value}, nil
 }
 
-func tryParseFloat(expr string) (parsedNum float64, remainder string, err error) {
-	num, expr := parseNextToken(expr)
-	parsedFloat, parseErr := strconv.ParseFloat(num, 64)
+// projection = path {"," path}
+func (tp *tokenParser) parseProjection() (exprNode, error) {
+	node := &projectionNode{}
+
+	for {
+		path, err := tp.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		node.paths = append(node.paths, path)
 
-	return parsedFloat, expr, parseErr
+		if !tp.peek().isSymbol(",") {
+			return node, nil
+		}
+		tp.next()
+	}
 }
 
-func tryParseBoolean(expr string) (result bool, remainder string, err error) {
-	nextToken, expr := parseNextToken(expr)
-	if strings.EqualFold(nextToken, "true") {
-		return true, expr, nil
+// Parses one or more comma separated nodes
+func (tp *tokenParser) parseSeparated(parseNode func() (exprNode, error)) (nodes []exprNode, commas []token, err error) {
+	for {
+		node, err := parseNode()
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes = append(nodes, node)
+
+		if !tp.peek().isSymbol(",") {
+			return nodes, commas, nil
+		}
+		commas = append(commas, tp.next())
 	}
-	if strings.EqualFold(nextToken, "false") {
-		return false, expr, nil
+}
+
+// Whether the next token starts a value. true, false and null are values rather than names.
+func (tp *tokenParser) atValue() bool {
+	tok := tp.peek()
+
+	switch {
+	case tok.kind == tokenString || tok.kind == tokenNumber || tok.kind == tokenBinary:
+		return true
+	case tok.isSymbol("-"):
+		return tp.isNegativeNumber()
+	case tok.isSymbol("[") || tok.isSymbol("<<") || tok.isSymbol("{"):
+		return true
+	default:
+		return tok.isKeyword("true") || tok.isKeyword("false") || tok.isKeyword("null")
 	}
+}
 
-	return false, expr, errors.New("Expected bool value at: " + expr)
+// A minus sign directly followed by a number. Otherwise a minus is a subtraction.
+func (tp *tokenParser) isNegativeNumber() bool {
+	next := tp.peekAt(1)
+	return tp.peek().isSymbol("-") && next.kind == tokenNumber && next.space == ""
 }
 
-func tryParseNull(expr string) (remainder string, err error) {
-	nextToken, expr := parseNextToken(expr)
-	if strings.EqualFold(nextToken, "null") {
-		return expr, nil
+// value = string | number | binary | true | false | null | list | set | map
+func (tp *tokenParser) parseValue() (*dynamodb.AttributeValue, error) {
+	tok := tp.peek()
+
+	switch {
+	case tok.kind == tokenString:
+		tp.next()
+		return &dynamodb.AttributeValue{S: &tok.value}, nil
+	case tok.kind == tokenNumber:
+		tp.next()
+		return tp.parseNumber(tok, tok.text)
+	case tok.kind == tokenBinary:
+		tp.next()
+		return tp.parseBinary(tok)
+	case tp.isNegativeNumber():
+		tp.next()
+		return tp.parseNumber(tok, "-"+tp.next().text)
+	case tok.isKeyword("true") || tok.isKeyword("false"):
+		tp.next()
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(strings.EqualFold(tok.text, "true"))}, nil
+	case tok.isKeyword("null"):
+		tp.next()
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, nil
+	case tok.isSymbol("["):
+		return tp.parseList()
+	case tok.isSymbol("<<"):
+		return tp.parseSet()
+	case tok.isSymbol("{"):
+		return tp.parseMap()
+	default:
+		return nil, tp.errorAt(tok, "Expected a value")
 	}
-
-	return expr, errors.New("Expected null value at: " + expr)
 }
 
-func tryParseList(expr string) (list *dynamodb.AttributeValue, remainder string, err error) {
-	if strings.HasPrefix(expr, "[") {
-		expr = expr[1:]
-	} else if strings.HasPrefix(expr, "<<") {
-		expr = expr[2:]
-	} else {
-		return nil, expr, errors.New("Expected list value at: " + expr)
+// Numbers are kept as written, so that they don't lose precision, after checking that DynamoDB
+// can store them
+func (tp *tokenParser) parseNumber(tok token, number string) (*dynamodb.AttributeValue, error) {
+	if err := validateNumber(number); err != nil {
+		return nil, tp.errorAt(tok, err.Error())
 	}
 
-	expr = strings.TrimLeft(expr, " ,")
+	return &dynamodb.AttributeValue{N: &number}, nil
+}
+
+// DynamoDB numbers have up to 38 significant digits, and magnitudes from 1E-130 to 9.99...E+125
+func validateNumber(number string) error {
+	match := rgxDecimal.FindStringSubmatch(number)
+	if match == nil {
+		return errors.New("Invalid number")
+	}
 
-	items := []*dynamodb.AttributeValue{}
-	for !strings.HasPrefix(expr, "]") && !strings.HasPrefix(expr, ">>") {
-		val, remainder, err := tryParseValue(expr)
+	integerPart, fractionPart, exponentPart := match[1], match[2], match[3]
 
-		if err != nil {
-			panic(err)
+	exponent := 0
+	if exponentPart != "" {
+		var err error
+		if exponent, err = strconv.Atoi(strings.TrimPrefix(exponentPart, "+")); err != nil {
+			return errors.New("Number is out of range")
 		}
+	}
 
-		items = append(items, val)
-		expr = strings.TrimLeft(remainder, " ,")
+	// The number is digits * 10^exponent, with digits stripped of leading and trailing zeros
+	digits := strings.TrimLeft(integerPart+fractionPart, "0")
+	if digits == "" {
+		return nil
 	}
+	exponent -= len(fractionPart)
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
 
-	if strings.HasPrefix(expr, "]") {
-		return &dynamodb.AttributeValue{L: items}, expr[1:], nil
+	if len(trimmed) > maxNumberDigits {
+		return fmt.Errorf("Number has more than %d significant digits", maxNumberDigits)
 	}
 
-	// Handle SS and NS
-	set := &dynamodb.AttributeValue{}
+	// Exponent of the number in scientific notation, i.e. d.ddd * 10^magnitude
+	magnitude := exponent + len(trimmed) - 1
+	if magnitude < minNumberMagnitude || magnitude > maxNumberMagnitude {
+		return errors.New("Number is out of range")
+	}
 
-	if items[0].N != nil {
-		for _, n := range items {
-			set.NS = append(set.NS, n.N)
+	return nil
+}
+
+// Binary values are written as base64, e.g. b64'SGVsbG8=', or hex, e.g. x'48656c6c6f'
+func (tp *tokenParser) parseBinary(tok token) (*dynamodb.AttributeValue, error) {
+	var decoded []byte
+	var err error
+
+	if strings.HasPrefix(strings.ToLower(tok.text), "x") {
+		if decoded, err = hex.DecodeString(tok.value); err != nil {
+			return nil, tp.errorAt(tok, "Invalid hex value")
 		}
 	} else {
-		for _, s := range items {
-			set.SS = append(set.SS, s.S)
+		if decoded, err = base64.StdEncoding.DecodeString(tok.value); err != nil {
+			return nil, tp.errorAt(tok, "Invalid base64 value")
 		}
 	}
 
-	return set, expr[2:], nil
+	return &dynamodb.AttributeValue{B: decoded}, nil
+}
+
+// Parses the values of a list or set up to the closing symbol. A trailing comma is allowed.
+func (tp *tokenParser) parseElements(close string) ([]*dynamodb.AttributeValue, error) {
+	open := tp.next()
+	elements := []*dynamodb.AttributeValue{}
+
+	for {
+		tok := tp.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, tp.errorAt(open, "Unterminated list or set")
+		case tok.isSymbol(close):
+			tp.next()
+			return elements, nil
+		}
 
+		value, err := tp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+
+		tok = tp.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, tp.errorAt(open, "Unterminated list or set")
+		case tok.isSymbol(","):
+			tp.next()
+		case !tok.isSymbol(close):
+			return nil, tp.errorAt(tok, "Expected ',' or '%s'", close)
+		}
+	}
 }
 
-func tryParseMap(expr string) (result *dynamodb.AttributeValue, remainder string, err error) {
-	if strings.HasPrefix(expr, "{") {
-		expr = expr[1:]
-	} else {
-		return nil, expr, errors.New("Expected map value at: " + expr)
+func (tp *tokenParser) parseList() (*dynamodb.AttributeValue, error) {
+	elements, err := tp.parseElements("]")
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.AttributeValue{L: elements}, nil
+}
+
+// Sets are written as <<'a', 'b'>>, <<1, 2>> or <<b64'AQ==', x'02'>>
+func (tp *tokenParser) parseSet() (*dynamodb.AttributeValue, error) {
+	open := tp.peek()
+	elements, err := tp.parseElements(">>")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 0 {
+		return nil, tp.errorAt(open, "Sets cannot be empty")
+	}
+
+	set := &dynamodb.AttributeValue{}
+	for _, element := range elements {
+		if elements[0].N != nil && element.N != nil {
+			set.NS = append(set.NS, element.N)
+		} else if elements[0].S != nil && element.S != nil {
+			set.SS = append(set.SS, element.S)
+		} else if elements[0].B != nil && element.B != nil {
+			set.BS = append(set.BS, element.B)
+		} else {
+			return nil, tp.errorAt(open, "Sets can only contain either strings, numbers or binary values")
+		}
 	}
 
-	expr = strings.TrimLeft(expr, " ")
+	return set, nil
+}
 
+// Maps are written as { key: value, `escaped key`: value }. Unescaped keys are read as written up
+// to the ':'.
+func (tp *tokenParser) parseMap() (*dynamodb.AttributeValue, error) {
+	open := tp.next()
 	root := make(map[string]*dynamodb.AttributeValue)
-	for !strings.HasPrefix(expr, "}") {
-		colonIdx := findWithOffset(expr, ":", 0)
 
-		name := strings.Trim(expr[0:colonIdx], " ")
-		expr = strings.TrimLeft(expr[colonIdx+1:], " ")
+	for {
+		tok := tp.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, tp.errorAt(open, "Unterminated map, expected '}'")
+		case tok.isSymbol("}"):
+			tp.next()
+			return &dynamodb.AttributeValue{M: root}, nil
+		}
 
-		val, remainder, err := tryParseValue(expr)
+		key, err := tp.parseMapKey(open)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
-		expr = strings.TrimLeft(remainder, " ,")
-		root[name] = val
+		value, err := tp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		root[key] = value
+
+		tok = tp.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, tp.errorAt(open, "Unterminated map, expected '}'")
+		case tok.isSymbol(","):
+			tp.next()
+		case !tok.isSymbol("}"):
+			return nil, tp.errorAt(tok, "Expected ',' or '}'")
+		}
 	}
-
-	attributeValue := dynamodb.AttributeValue{M: root}
-	return &attributeValue, expr[1:], nil
 }
 
-func startsWithKeyword(str string) *string {
-	for _, prefix := range keywords {
-		if strings.HasPrefix(strings.ToLower(str), prefix) {
-			if prefix == "<" && strings.HasPrefix(str, "<<") {
-				// edge case for set start - "<" is both a comparison operator and part of "<<"
-				continue
-			}
-			found := str[:len(prefix)] // preserve case of input string
-			return &found
+// Reads a map key and the ':' after it
+func (tp *tokenParser) parseMapKey(open token) (string, error) {
+	first := tp.peek()
+	if first.kind == tokenEscapedName {
+		tp.next()
+		if _, err := tp.expectSymbol(":"); err != nil {
+			return "", err
 		}
+		return first.value, nil
 	}
 
-	return nil
+	last := first
+	for {
+		tok := tp.peek()
+		switch {
+		case tok.isSymbol(":"):
+			if tok == first {
+				return "", tp.errorAt(tok, "Expected a map key")
+			}
+			tp.next()
+			return tp.expr[first.pos:last.end()], nil
+		case tok.kind == tokenEOF:
+			return "", tp.errorAt(open, "Unterminated map, expected '}'")
+		case tok.kind == tokenWord || tok.kind == tokenNumber || tok.isSymbol("-") || tok.isSymbol("+") || tok.isSymbol("."):
+			last = tp.next()
+		default:
+			return "", tp.errorAt(tok, "Expected ':' after map key")
+		}
+	}
 }
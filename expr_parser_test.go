@@ -24,7 +24,8 @@ func Test_query_keyOnly(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("pk = 'someStr' AND sk>=123")
+	expr, err := exprParser.parseGenericExpression("pk = 'someStr' AND sk>=123")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedKeyCondition, *expr)
@@ -54,7 +55,8 @@ func Test_query_complexNames(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("a.b=1 AND c.d[2] > 2 OR 3 = e.f[2].g")
+	expr, err := exprParser.parseGenericExpression("a.b=1 AND c.d[2] > 2 OR 3 = e.f[2].g")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -80,8 +82,10 @@ func Test_query_keyAndProj(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("pk = 'someStr'")
-	proj := exprParser.parseProjectionExpression("pk, field0")
+	expr, err := exprParser.parseGenericExpression("pk = 'someStr'")
+	require.NoError(t, err)
+	proj, err := exprParser.parseProjectionExpression("pk, field0")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedKeyCondition, *expr)
@@ -102,7 +106,8 @@ func Test_query_filterOnly(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 = 'someStr'")
+	expr, err := exprParser.parseGenericExpression("field0 = 'someStr'")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -128,8 +133,10 @@ func Test_query_filterAndProj(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field1 = 'someStr'")
-	proj := exprParser.parseProjectionExpression("field2,field3")
+	expr, err := exprParser.parseGenericExpression("field1 = 'someStr'")
+	require.NoError(t, err)
+	proj, err := exprParser.parseProjectionExpression("field2,field3")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -161,9 +168,12 @@ func Test_query_keyAndFilterAndProj(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	key := exprParser.parseGenericExpression("pk = 'someStr'")
-	filter := exprParser.parseGenericExpression("field0 = 'aValue' AND field1 = 'anotherValue'")
-	proj := exprParser.parseProjectionExpression("pk,aValue")
+	key, err := exprParser.parseGenericExpression("pk = 'someStr'")
+	require.NoError(t, err)
+	filter, err := exprParser.parseGenericExpression("field0 = 'aValue' AND field1 = 'anotherValue'")
+	require.NoError(t, err)
+	proj, err := exprParser.parseProjectionExpression("pk,aValue")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedKeyCondition, *key)
@@ -193,7 +203,8 @@ func Test_query_types(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 = 'someStr' AND field1 = 10 AND field2 = 10.12345 AND field3 = true")
+	expr, err := exprParser.parseGenericExpression("field0 = 'someStr' AND field1 = 10 AND field2 = 10.12345 AND field3 = true")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -213,7 +224,8 @@ func Test_query_list(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 = ['someStr', 10]")
+	expr, err := exprParser.parseGenericExpression("field0 = ['someStr', 10]")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -238,7 +250,8 @@ func Test_query_sets(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 = <<'someStr0','someStr1'>> AND field1 = << 123 , 456.789  >>")
+	expr, err := exprParser.parseGenericExpression("field0 = <<'someStr0','someStr1'>> AND field1 = << 123 , 456.789  >>")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -266,7 +279,8 @@ func Test_query_map(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 = { mapField0: 'mapValue0', mapField1: 123 }")
+	expr, err := exprParser.parseGenericExpression("field0 = { mapField0: 'mapValue0', mapField1: 123 }")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -297,8 +311,10 @@ func Test_query_specialTokens(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	key := exprParser.parseGenericExpression("((pk = 1000) AND NOT (sk = 123.222))")
-	filter := exprParser.parseGenericExpression("NOT field0 > 'str1' OR field1 < 15")
+	key, err := exprParser.parseGenericExpression("((pk = 1000) AND NOT (sk = 123.222))")
+	require.NoError(t, err)
+	filter, err := exprParser.parseGenericExpression("NOT field0 > 'str1' OR field1 < 15")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedKeyCondition, *key)
@@ -331,8 +347,10 @@ func Test_query_comparators(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	key := exprParser.parseGenericExpression("pk = 1000")
-	filter := exprParser.parseGenericExpression("field0 > 'str1' OR field1 < 15 OR field2 >= 10 OR field3 <= 11 OR field4 <> 12")
+	key, err := exprParser.parseGenericExpression("pk = 1000")
+	require.NoError(t, err)
+	filter, err := exprParser.parseGenericExpression("field0 > 'str1' OR field1 < 15 OR field2 >= 10 OR field3 <= 11 OR field4 <> 12")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedKeyCondition, *key)
@@ -356,7 +374,8 @@ func Test_query_between(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field0 BETWEEN 'value0' AND 'value1'")
+	expr, err := exprParser.parseGenericExpression("field0 BETWEEN 'value0' AND 'value1'")
+	require.NoError(t, err)
 
 	require.Equal(t, expectedFilterCondition, *expr)
 	require.Equal(t, expectedNames, exprParser.getNames())
@@ -379,7 +398,8 @@ func Test_query_in(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("field1 IN ('value0', 'value1', 'value2')")
+	expr, err := exprParser.parseGenericExpression("field1 IN ('value0', 'value1', 'value2')")
+	require.NoError(t, err)
 
 	require.Equal(t, expectedFilterCondition, *expr)
 	require.Equal(t, expectedNames, exprParser.getNames())
@@ -406,7 +426,8 @@ func Test_query_functions(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("begins_with(field0, 'someStr0') OR attribute_exists(field1) OR attribute_not_exists(field2) OR attribute_type(field3, 'S') OR contains(field4, 'someStr1')")
+	expr, err := exprParser.parseGenericExpression("begins_with(field0, 'someStr0') OR attribute_exists(field1) OR attribute_not_exists(field2) OR attribute_type(field3, 'S') OR contains(field4, 'someStr1')")
+	require.NoError(t, err)
 
 	// then
 	require.Equal(t, expectedFilterCondition, *expr)
@@ -434,7 +455,8 @@ func Test_query_extraSpaces(t *testing.T) {
 
 	// when
 	exprParser := newExprParser()
-	expr := exprParser.parseGenericExpression("   field0   IN  ( 'value0',   'value1','value2'  ) AND   begins_with(   field1  ,  'value3'   )  AND field2   =   123")
+	expr, err := exprParser.parseGenericExpression("   field0   IN  ( 'value0',   'value1','value2'  ) AND   begins_with(   field1  ,  'value3'   )  AND field2   =   123")
+	require.NoError(t, err)
 
 	require.Equal(t, expectedFilterCondition, *expr)
 	require.Equal(t, expectedNames, exprParser.getNames())
@@ -443,30 +465,35 @@ func Test_query_extraSpaces(t *testing.T) {
 
 func Test_query_projection(t *testing.T) {
 	exprParser := newExprParser()
-	empty := exprParser.parseProjectionExpression("   ")
+	empty, err := exprParser.parseProjectionExpression("   ")
+	require.NoError(t, err)
 	require.Nil(t, empty)
 
 	exprParser = newExprParser()
-	single := exprParser.parseProjectionExpression("a")
+	single, err := exprParser.parseProjectionExpression("a")
+	require.NoError(t, err)
 	require.Equal(t, "#0", *single)
 	require.Equal(t, "a", *exprParser.getNames()["#0"], "pk")
 
 	exprParser = newExprParser()
-	multiple := exprParser.parseProjectionExpression("a,b,c")
+	multiple, err := exprParser.parseProjectionExpression("a,b,c")
+	require.NoError(t, err)
 	require.Equal(t, "#0,#1,#2", *multiple)
 	require.Equal(t, *exprParser.getNames()["#0"], "a")
 	require.Equal(t, *exprParser.getNames()["#1"], "b")
 	require.Equal(t, *exprParser.getNames()["#2"], "c")
 
 	exprParser = newExprParser()
-	multipleWithSpaces := exprParser.parseProjectionExpression("a, b,c")
+	multipleWithSpaces, err := exprParser.parseProjectionExpression("a, b,c")
+	require.NoError(t, err)
 	require.Equal(t, "#0,#1,#2", *multipleWithSpaces)
 	require.Equal(t, *exprParser.getNames()["#0"], "a")
 	require.Equal(t, *exprParser.getNames()["#1"], "b")
 	require.Equal(t, *exprParser.getNames()["#2"], "c")
 
 	exprParser = newExprParser()
-	complexNames := exprParser.parseProjectionExpression("a.b[0].c, d.e, f.g[0]")
+	complexNames, err := exprParser.parseProjectionExpression("a.b[0].c, d.e, f.g[0]")
+	require.NoError(t, err)
 	require.Equal(t, "#0.#1[0].#2,#3.#4,#5.#6[0]", *complexNames)
 }
 
@@ -482,6 +509,81 @@ func Test_types_stringEscaping(t *testing.T) {
 	require.Equal(t, "\"Something\", he said", *exprParser.getValues()[":2"].S)
 }
 
+func Test_types_numberPrecision(t *testing.T) {
+	// given
+	expectedValues := map[string]*dynamodb.AttributeValue{
+		":0": number("12345678901234567890123"),
+		":1": number("0.1000000000000000000001"),
+		":2": number("-1.5E+125"),
+		":3": {NS: []*string{name("99999999999999999999999999999999999999"), name("1e-130")}},
+	}
+
+	// when
+	exprParser := newExprParser()
+	_, err := exprParser.parseConditionExpression("a = 12345678901234567890123 AND b = 0.1000000000000000000001 AND c = -1.5E+125 AND d = <<99999999999999999999999999999999999999, 1e-130>>")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, expectedValues, exprParser.getValues())
+}
+
+func Test_types_numberLimits(t *testing.T) {
+	valid := []string{"0", "0.000", "-0", "100000000000000000000000000000000000000000", "9.9999999999999999999999999999999999999E+125", "1E-130", "0.0001e-126"}
+	for _, num := range valid {
+		require.NoError(t, validateNumber(num), num)
+	}
+
+	invalid := map[string]string{
+		"123456789012345678901234567890123456789":  "Number has more than 38 significant digits",
+		"1.00000000000000000000000000000000000001": "Number has more than 38 significant digits",
+		"1E+126":                 "Number is out of range",
+		"10E+125":                "Number is out of range",
+		"1E-131":                 "Number is out of range",
+		"0.01e-129":              "Number is out of range",
+		"1e99999999999999999999": "Number is out of range",
+	}
+	for num, msg := range invalid {
+		require.EqualError(t, validateNumber(num), msg, num)
+	}
+
+	_, err := newExprParser().parseConditionExpression("a = 1E+126")
+	require.True(t, isParseError(err))
+	require.Equal(t, "Number is out of range\n  a = 1E+126\n      ^", err.Error())
+}
+
+func Test_types_binary(t *testing.T) {
+	// given
+	expectedValues := map[string]*dynamodb.AttributeValue{
+		":0": {B: []byte("Hello")},
+		":1": {B: []byte("Hello")},
+		":2": {BS: [][]byte{{1}, {2}}},
+	}
+
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseConditionExpression("a = b64'SGVsbG8=' AND b = X'48656C6C6F' AND c = <<b64'AQ==', x'02'>>")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, "#0 = :0 AND #1 = :1 AND #2 = :2", *expr)
+	require.Equal(t, expectedValues, exprParser.getValues())
+}
+
+func Test_errors_binary(t *testing.T) {
+	malformed := map[string]string{
+		"a = b64'not base64'": "Invalid base64 value",
+		"a = x'abc'":          "Invalid hex value",
+		"a = x'ab":            "Unterminated string",
+		"a = <<x'01', 'a'>>":  "Sets can only contain either strings, numbers or binary values",
+	}
+
+	for expr, msg := range malformed {
+		_, err := newExprParser().parseConditionExpression(expr)
+		require.True(t, isParseError(err), expr)
+		require.Contains(t, err.Error(), msg, expr)
+	}
+}
+
 func Test_names_nameEscaping(t *testing.T) {
 	exprParser := newExprParser()
 	exprParser.parseGenericExpression("`size` = 123")
@@ -492,7 +594,8 @@ func Test_names_nameEscaping(t *testing.T) {
 	require.Equal(t, "123", *exprParser.getNames()["#0"])
 
 	exprParser = newExprParser()
-	escapedComplexName := exprParser.parseGenericExpression("`a`.b[3].`c d`.`e`[2] = 'abcd'")
+	escapedComplexName, err := exprParser.parseGenericExpression("`a`.b[3].`c d`.`e`[2] = 'abcd'")
+	require.NoError(t, err)
 	require.Equal(t, "#0.#1[3].#2.#3[2] = :0", *escapedComplexName)
 	require.Equal(t, "a", *exprParser.getNames()["#0"])
 	require.Equal(t, "b", *exprParser.getNames()["#1"])
@@ -500,6 +603,195 @@ func Test_names_nameEscaping(t *testing.T) {
 	require.Equal(t, "e", *exprParser.getNames()["#3"])
 }
 
+func Test_names_keywordPrefixes(t *testing.T) {
+	// given
+	expectedCondition := "#0 = :0 AND #1 <> :1 OR NOT #2 > :2 AND #3 = :3 AND #4 < :4 AND #5 IN (:5)"
+
+	expectedNames := map[string]*string{
+		"#0": name("order_id"),
+		"#1": name("notes"),
+		"#2": name("size_bytes"),
+		"#3": name("settings"),
+		"#4": name("addedAt"),
+		"#5": name("index"),
+	}
+
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseConditionExpression("order_id = 1 AND notes <> 'a' OR NOT size_bytes > 2 AND settings = 3 AND addedAt < 4 AND index IN (5)")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, expectedCondition, *expr)
+	require.Equal(t, expectedNames, exprParser.getNames())
+}
+
+// Names with hyphens were written without quoting before the parser was rewritten
+func Test_names_hyphens(t *testing.T) {
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseGenericExpression("created-at = 1 AND attribute_exists(order-id)")
+	require.NoError(t, err)
+	update, err := exprParser.parseUpdateExpression("SET total = total-discount, left = left - 1")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, "#0 = :0 AND attribute_exists(#1)", *expr)
+	require.Equal(t, "SET #2 = #3, #4 = #5 - :1", *update)
+	require.Equal(t, map[string]*string{
+		"#0": name("created-at"),
+		"#1": name("order-id"),
+		"#2": name("total"),
+		"#3": name("total-discount"),
+		"#4": name("left"),
+		"#5": name("left"),
+	}, exprParser.getNames())
+}
+
+func Test_names_keywordsAsNames(t *testing.T) {
+	// given
+	expectedCondition := "#0 = :0 AND #1.#2 = :1"
+
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseGenericExpression("set = 1 AND and.or = 2")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, expectedCondition, *expr)
+	require.Equal(t, map[string]*string{"#0": name("set"), "#1": name("and"), "#2": name("or")}, exprParser.getNames())
+}
+
+func Test_update_clauses(t *testing.T) {
+	// given
+	expectedUpdate := "SET #0 = #1 + :0, #2 = list_append(#3, :1) REMOVE #4[1], #5 ADD #6 :2 DELETE #7 :3"
+
+	expectedNames := map[string]*string{
+		"#0": name("counter"),
+		"#1": name("counter"),
+		"#2": name("tags"),
+		"#3": name("tags"),
+		"#4": name("items"),
+		"#5": name("notes"),
+		"#6": name("size_bytes"),
+		"#7": name("settings"),
+	}
+
+	expectedValues := map[string]*dynamodb.AttributeValue{
+		":0": integer(1),
+		":1": {L: []*dynamodb.AttributeValue{str("a")}},
+		":2": integer(-5),
+		":3": stringSet([]string{"x"}),
+	}
+
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseUpdateExpression("SET counter = counter + 1, tags = list_append(tags, ['a']) REMOVE items[1], notes ADD size_bytes -5 DELETE settings <<'x'>>")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, expectedUpdate, *expr)
+	require.Equal(t, expectedNames, exprParser.getNames())
+	require.Equal(t, expectedValues, exprParser.getValues())
+}
+
+func Test_update_subtraction(t *testing.T) {
+	// when
+	exprParser := newExprParser()
+	expr, err := exprParser.parseGenericExpression("set a = b - 1, c = if_not_exists(c, -1)")
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, "set #0 = #1 - :0, #2 = if_not_exists(#3, :1)", *expr)
+	require.Equal(t, map[string]*dynamodb.AttributeValue{":0": integer(1), ":1": integer(-1)}, exprParser.getValues())
+}
+
+func Test_errors_grammar(t *testing.T) {
+	malformed := map[string]string{
+		"pk":                   "Expected a comparison",
+		"pk = ":                "Expected a name or value",
+		"pk = 1 sk = 2":        "Unexpected input",
+		"(pk = 1":              "Expected ')'",
+		"pk BETWEEN 1 OR 2":    "Expected AND",
+		"pk IN 1":              "Expected '('",
+		"starts_with(pk, 'a')": "Unknown function 'starts_with'",
+		"pk = 1 AND":           "Expected a name or value",
+		"pk = 1 ^ 2":           "Unexpected character '^'",
+	}
+
+	for expr, msg := range malformed {
+		_, err := newExprParser().parseConditionExpression(expr)
+		require.Error(t, err, expr)
+		require.Contains(t, err.Error(), msg, expr)
+	}
+
+	_, err := newExprParser().parseUpdateExpression("UPDATE a = 1")
+	require.Contains(t, err.Error(), "Expected SET, REMOVE, ADD or DELETE")
+
+	_, err = newExprParser().parseUpdateExpression("SET a 1")
+	require.Contains(t, err.Error(), "Expected '='")
+}
+
+func Test_errors_malformedValues(t *testing.T) {
+	malformed := []string{
+		"pk = 'unterminated",
+		"pk = 'trailing escape\\",
+		"pk = 'bad \\escape'",
+		"pk = [1, 2",
+		"pk = { a: 1",
+		"pk = { a 1 }",
+		"pk = <<>>",
+		"pk = <<1, 'a'>>",
+		"`unterminated = 1",
+		"a[1 = 1",
+	}
+
+	for _, expr := range malformed {
+		_, err := newExprParser().parseGenericExpression(expr)
+		require.Error(t, err, expr)
+		require.True(t, isParseError(err), expr)
+	}
+}
+
+func Test_errors_parseItem(t *testing.T) {
+	item, err := parseItem(" { pk: 'a', sk: 1 } ")
+	require.NoError(t, err)
+	require.Equal(t, map[string]*dynamodb.AttributeValue{"pk": str("a"), "sk": integer(1)}, item)
+
+	_, err = parseItem("pk: 'a'")
+	require.True(t, isParseError(err))
+
+	_, err = parseItem("{ pk: 'a' } extra")
+	require.True(t, isParseError(err))
+}
+
+func Test_errors_position(t *testing.T) {
+	_, err := newExprParser().parseGenericExpression("pk = 'someStr AND sk = 1")
+	require.Equal(t, "Could not parse --key: Unterminated string\n"+
+		"  pk = 'someStr AND sk = 1\n"+
+		"       ^", withFlag(err, "--key").Error())
+
+	_, err = parseItem("{ pk: 'a', sk: [1, 2 }")
+	require.Equal(t, "Could not parse --item: Expected ',' or ']'\n"+
+		"  { pk: 'a', sk: [1, 2 }\n"+
+		"                       ^", withFlag(err, "--item").Error())
+
+	_, err = parseItem("{ pk: 'a', sk: [1, 2")
+	require.Equal(t, "Unterminated list or set\n"+
+		"  { pk: 'a', sk: [1, 2\n"+
+		"                 ^", err.Error())
+
+	_, err = parseItem(" { pk: 'a', sk: 1  ")
+	require.Equal(t, "Unterminated map, expected '}'\n"+
+		"   { pk: 'a', sk: 1  \n"+
+		"   ^", err.Error())
+
+	_, err = newExprParser().parseProjectionExpression("a, `b ")
+	require.Equal(t, "Unterminated name\n"+
+		"  a, `b\n"+
+		"     ^", err.Error())
+}
+
 func name(str string) *string {
 	return &str
 }
@@ -515,6 +807,10 @@ func integer(integer int) *dynamodb.AttributeValue {
 	return &out
 }
 
+func number(number string) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: &number}
+}
+
 func float(float float64) *dynamodb.AttributeValue {
 	str := fmt.Sprint(float)
 	out := dynamodb.AttributeValue{N: &str}
@@ -4,9 +4,9 @@ go 1.16
 
 require (
 	github.com/aws/aws-sdk-go v1.39.2
-	github.com/bradfitz/slice v0.0.0-20180809154707-2b758aa73013 // indirect
+	github.com/bradfitz/slice v0.0.0-20180809154707-2b758aa73013
 	github.com/c-bata/go-prompt v0.2.6
-	github.com/jessevdk/go-flags v1.5.0 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/jessevdk/go-flags v1.5.0
+	github.com/stretchr/testify v1.7.0
 	go4.org v0.0.0-20201209231011-d4a079459e60 // indirect
 )
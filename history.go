@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+)
+
+const maxHistory = 1000
+
+// Commands entered in the shell, saved to a file so that they're available in later sessions
+type history struct {
+	path    string // empty when the history isn't saved
+	entries []string
+	search  historySearch
+}
+
+// State of a reverse search, continued by pressing Ctrl-R again
+type historySearch struct {
+	term  string
+	index int    // searching continues with the entries before this one
+	shown string // the entry put in the input, a new search starts when the input is changed
+}
+
+var rgxFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// The history file in the user's config directory, e.g. ~/.config/dynshell/history. When separate,
+// each region and endpoint has a history of its own.
+func historyPath(separate bool, region string, endpointUrl string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := "history"
+	if separate {
+		name += "-" + rgxFileNameUnsafe.ReplaceAllString(region, "_")
+		if endpointUrl != "" {
+			name += "-" + rgxFileNameUnsafe.ReplaceAllString(endpointUrl, "_")
+		}
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// Loads the history saved at path, which doesn't need to exist yet. Files over the size limit are
+// trimmed to the most recent commands.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		if err := h.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Adds a command and appends it to the history file. Empty commands and repeats of the previous
+// command are skipped.
+func (h *history) add(command string) error {
+	h.search = historySearch{}
+
+	command = strings.TrimSpace(command)
+	if command == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == command) {
+		return nil
+	}
+
+	h.entries = append(h.entries, command)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(command + "\n")
+
+	return err
+}
+
+func (h *history) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+}
+
+// Replaces the input with the most recent command containing it, and with older matches each time
+// it's pressed again. The input is left as it is when there are no more matches.
+func (h *history) reverseSearch(buf *prompt.Buffer) {
+	text := buf.Text()
+	if h.search.shown == "" || text != h.search.shown {
+		h.search = historySearch{term: text, index: len(h.entries)}
+	}
+
+	for i := h.search.index - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if !strings.Contains(entry, h.search.term) || entry == h.search.shown {
+			continue
+		}
+
+		h.search.index, h.search.shown = i, entry
+
+		buf.CursorRight(len([]rune(text)))
+		buf.DeleteBeforeCursor(len([]rune(text)))
+		buf.InsertText(entry, false, true)
+		return
+	}
+}
+
+// Lists the commands in the history, or the ones containing the search term
+func (e executor) handleHistory(term string) error {
+	if e.state.history == nil {
+		return newValidationError("History is only kept in the interactive shell")
+	}
+
+	term = strings.TrimSpace(term)
+	for i, entry := range e.state.history.entries {
+		if strings.Contains(entry, term) {
+			fmt.Printf("%5d  %s\n", i+1, entry)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_history_add_savesCommands(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "dynshell", "history")
+	h, err := loadHistory(path)
+	require.NoError(t, err)
+
+	// when
+	for _, command := range []string{"use Orders", " ", "scan", "scan", "get -k \"{pk: 'a'}\""} {
+		require.NoError(t, h.add(command))
+	}
+	loaded, err := loadHistory(path)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []string{"use Orders", "scan", "get -k \"{pk: 'a'}\""}, loaded.entries)
+}
+
+func Test_loadHistory_trimsToLimit(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "history")
+	lines := []string{}
+	for i := 0; i < maxHistory+10; i++ {
+		lines = append(lines, fmt.Sprintf("get -k \"{pk: %d}\"", i))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+
+	// when
+	h, err := loadHistory(path)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, lines[10:], h.entries)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, maxHistory, strings.Count(string(data), "\n"))
+}
+
+func Test_historyPath_separate(t *testing.T) {
+	// when
+	path, err := historyPath(true, "us-east-1", "http://localhost:8000")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "history-us-east-1-http_localhost_8000", filepath.Base(path))
+}
+
+func Test_history_reverseSearch(t *testing.T) {
+	// given
+	h := &history{entries: []string{"scan", "query -k \"pk = 'a'\"", "use Orders", "query -k \"pk = 'b'\""}}
+	buf := prompt.NewBuffer()
+	buf.InsertText("query", false, true)
+
+	// when
+	h.reverseSearch(buf)
+	first := buf.Text()
+	h.reverseSearch(buf)
+	second := buf.Text()
+	h.reverseSearch(buf)
+	last := buf.Text()
+
+	// then
+	require.Equal(t, "query -k \"pk = 'b'\"", first)
+	require.Equal(t, "query -k \"pk = 'a'\"", second)
+	require.Equal(t, second, last)
+}
+
+func Test_history_reverseSearch_newSearchAfterEdit(t *testing.T) {
+	// given
+	h := &history{entries: []string{"scan", "use Orders", "query -k \"pk = 'a'\""}}
+	buf := prompt.NewBuffer()
+	h.reverseSearch(buf)
+
+	// when
+	buf.DeleteBeforeCursor(len(buf.Text()))
+	buf.InsertText("sc", false, true)
+	h.reverseSearch(buf)
+
+	// then
+	require.Equal(t, "scan", buf.Text())
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -8,11 +13,18 @@ import (
 	"github.com/jessevdk/go-flags"
 )
 
+// Options left empty are read from the config file
 type opts struct {
-	// TODO get region from aws config?
-	EndpointUrl string `long:"endpoint-url" description:"Override the default URL with a given URL"`
-	Region      string `long:"region" description:"The region to use" required:"true"`
-	Verbose     bool   `short:"v" long:"verbose" description:"Verbose output"`
+	Config          string `long:"config" description:"Config file to use instead of dynshell/config in the user's config directory"`
+	EndpointUrl     string `long:"endpoint-url" description:"Override the default URL with a given URL, or the name of an endpoint in the config file"`
+	Region          string `long:"region" description:"The region to use, by default the one in the config file or the AWS config"`
+	Profile         string `long:"profile" description:"The AWS profile to use"`
+	Table           string `long:"table" description:"The table to use at startup"`
+	Command         string `short:"c" long:"command" description:"Run the given commands, separated by ';', and exit"`
+	File            string `short:"f" long:"file" description:"Run the commands in a script file and exit"`
+	Output          string `short:"o" long:"output" description:"Output format" choice:"native" choice:"json" choice:"dynamodb-json" choice:"jsonl" choice:"csv" choice:"table"`
+	Verbose         bool   `short:"v" long:"verbose" description:"Verbose output"`
+	SeparateHistory bool   `long:"separate-history" description:"Keep a separate command history for each region and endpoint"`
 }
 
 type tableContext struct {
@@ -20,29 +32,136 @@ type tableContext struct {
 	hashAttribute  string
 	rangeAttribute string
 	indexes        []string
+	indexKeys      map[string]keySchema // key attributes of each index, by index name
+	attributeTypes map[string]string    // types of the key attributes of the table and its indexes
+	attributes     attributeCatalogue   // attributes seen in the table's items, for completion
 	allTables      []*string
 }
 
-func createDynamo(endpointUrl *string, region *string) *dynamodb.DynamoDB {
-	session := session.Must(session.NewSession(&aws.Config{
-		Endpoint: endpointUrl,
-		Region:   region,
-	}))
+type keySchema struct {
+	hashAttribute  string
+	rangeAttribute string
+}
+
+// Creates the client, with the region and credentials of the profile in the AWS config unless
+// they're overridden
+func createDynamo(endpointUrl string, region string, profile string) (*dynamodb.DynamoDB, error) {
+	awsConfig := aws.Config{}
+	if endpointUrl != "" {
+		awsConfig.Endpoint = &endpointUrl
+	}
+	if region != "" {
+		awsConfig.Region = &region
+	}
+
+	session, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConfig,
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return dynamodb.New(session)
+	if aws.StringValue(session.Config.Region) == "" {
+		return nil, fmt.Errorf("No region given, use --region or set one in the config file or the AWS config")
+	}
+
+	return dynamodb.New(session), nil
+}
+
+// Reads the file given with --config, or the default config file if there is one
+func readConfig(path string) (config, error) {
+	if path != "" {
+		return loadConfig(path, false)
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return config{}, nil // no config directory to read it from
+	}
+
+	return loadConfig(filepath.Join(dir, "config"), true)
 }
 
 func main() {
 	opts := opts{}
 	_, err := flags.Parse(&opts)
 	if err != nil {
-		panic(err)
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			os.Exit(exitOk)
+		}
+		os.Exit(exitValidation)
+	}
+
+	cfg, err := readConfig(opts.Config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitValidation)
+	}
+	opts = cfg.apply(opts)
+
+	dynamo, err := createDynamo(opts.EndpointUrl, opts.Region, opts.Profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitValidation)
+	}
+	tableCtx := tableContext{}
+
+	executor := newExecutor(dynamo, &tableCtx, opts.Output, opts.Verbose)
+	executor.aliases = cfg.aliases
+
+	isInteractive := opts.Command == "" && opts.File == "" && isTerminal(os.Stdin)
+
+	// Tables are only listed and sampled for completion, which scripts don't need
+	if isInteractive {
+		executor.state.sample = interactiveSample
+
+		if err := executor.refreshTables(); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not list tables: %v\n", err)
+		}
+	}
+
+	if opts.Table != "" {
+		if err := executor.handleUse(opts.Table); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if !isInteractive {
+				os.Exit(exitCode(err))
+			}
+		}
+	}
+
+	if opts.Command != "" {
+		os.Exit(executor.runScript(opts.Command))
 	}
 
-	dynamo := createDynamo(&opts.EndpointUrl, &opts.Region)
-	listTablesOutput, _ := dynamo.ListTables(&dynamodb.ListTablesInput{})
-	tableCtx := tableContext{
-		allTables: listTablesOutput.TableNames,
+	if opts.File != "" {
+		script, err := os.ReadFile(opts.File)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitError)
+		}
+		os.Exit(executor.runScript(string(script)))
+	}
+
+	if !isTerminal(os.Stdin) {
+		script, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitError)
+		}
+		os.Exit(executor.runScript(string(script)))
+	}
+
+	history := openHistory(opts.SeparateHistory, *dynamo.Config.Region, opts.EndpointUrl)
+	executor.state.history = history
+
+	execute := func(input string) {
+		if err := history.add(input); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save history, it will only be kept for this session: %v\n", err)
+			history.path = ""
+		}
+		executor.execute(input)
 	}
 
 	livePrefix := func() (prefix string, live bool) {
@@ -50,13 +169,16 @@ func main() {
 		if tableCtx.name != "" {
 			promptPrefix += ":" + tableCtx.name
 		}
+		if tx := executor.state.transaction; tx != nil {
+			promptPrefix += fmt.Sprintf(" [tx: %d]", len(tx.items))
+		}
 		promptPrefix += "> "
 		return promptPrefix, true
 	}
 
 	p := prompt.New(
-		newExecutor(dynamo, &tableCtx, opts.Verbose).execute,
-		newCompleter(&tableCtx).complete,
+		execute,
+		newCompleter(&tableCtx, cfg.aliases).complete,
 		prompt.OptionTitle("dynshell"),
 		prompt.OptionLivePrefix(livePrefix),
 		prompt.OptionAddKeyBind(prompt.KeyBind{
@@ -67,8 +189,29 @@ func main() {
 			Key: prompt.ControlRight,
 			Fn:  prompt.GoRightWord,
 		}),
-		prompt.OptionCompletionWordSeparator("{- \""),
+		prompt.OptionAddKeyBind(prompt.KeyBind{
+			Key: prompt.ControlR,
+			Fn:  history.reverseSearch,
+		}),
+		prompt.OptionCompletionWordSeparator(completionWordSeparator),
+		// copied, as go-prompt appends to it
+		prompt.OptionHistory(append([]string{}, history.entries...)),
 	)
 
 	p.Run()
 }
+
+// Loads the history file, falling back to a history which is only kept for this session
+func openHistory(separate bool, region string, endpointUrl string) *history {
+	path, err := historyPath(separate, region, endpointUrl)
+	if err == nil {
+		var h *history
+		if h, err = loadHistory(path); err == nil {
+			return h
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Could not load history, it will only be kept for this session: %v\n", err)
+
+	return &history{}
+}
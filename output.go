@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	outputNative     = "native"
+	outputJson       = "json"
+	outputDynamoJson = "dynamodb-json"
+	outputJsonLines  = "jsonl"
+	outputCsv        = "csv"
+	outputTable      = "table"
+)
+
+var outputFormats []string = []string{outputNative, outputJson, outputDynamoJson, outputJsonLines, outputCsv, outputTable}
+
+// Prints the output of a command in the session's output format
+func (e executor) printOutput(output interface{}) error {
+	keyAttributes := []string{e.tableCtx.hashAttribute, e.tableCtx.rangeAttribute}
+
+	return writeOutput(os.Stdout, e.state.output, e.state.binary, output, keyAttributes)
+}
+
+// Prints informational messages, which aren't part of the command output. These go to stderr when
+// using a machine readable format, so that they don't interfere with piping the output.
+func (e executor) printInfo(msg string) {
+	if e.state.output == outputNative {
+		fmt.Println(msg)
+	} else {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+}
+
+// Writes output in the given format. JSON, JSON Lines, CSV and table formats only include the items
+// of an output, when it has any. keyAttributes determines which columns come first in CSV and table
+// formats. Binary values are displayed in the binary encoding, except in DynamoDB JSON, where they're
+// always base64.
+func writeOutput(w io.Writer, format string, binary string, output interface{}, keyAttributes []string) error {
+	items, isItemOutput := findItems(output)
+
+	switch format {
+	case outputNative:
+		_, err := fmt.Fprintln(w, prettify(output, binary))
+		return err
+	case outputDynamoJson:
+		return writeJson(w, output, true)
+	case outputJson:
+		if !isItemOutput {
+			return writeJson(w, output, true)
+		}
+
+		if single, isSingle := findSingleItem(output); isSingle {
+			return writeJson(w, toPlainItem(single, binary), true)
+		}
+
+		plainItems := []interface{}{}
+		for _, item := range items {
+			plainItems = append(plainItems, toPlainItem(item, binary))
+		}
+		return writeJson(w, plainItems, true)
+	case outputJsonLines:
+		if !isItemOutput {
+			return writeJson(w, output, false)
+		}
+
+		for _, item := range items {
+			err := writeJson(w, toPlainItem(item, binary), false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputCsv:
+		if !isItemOutput {
+			_, err := fmt.Fprintln(w, prettify(output, binary))
+			return err
+		}
+
+		return writeCsv(w, items, keyAttributes, binary)
+	case outputTable:
+		if !isItemOutput {
+			_, err := fmt.Fprintln(w, prettify(output, binary))
+			return err
+		}
+
+		return writeTable(w, items, keyAttributes, binary)
+	default:
+		return fmt.Errorf("Unknown output format: %s", format)
+	}
+}
+
+// Finds the items in a command output - either the Items of a read, or the Item/Attributes of a
+// single item read or write.
+func findItems(output interface{}) (items []map[string]*dynamodb.AttributeValue, isItemOutput bool) {
+	v := reflect.Indirect(reflect.ValueOf(output))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	if field := v.FieldByName("Items"); field.IsValid() && !field.IsNil() {
+		return field.Interface().([]map[string]*dynamodb.AttributeValue), true
+	}
+
+	if single, isSingle := findSingleItem(output); isSingle {
+		return []map[string]*dynamodb.AttributeValue{single}, true
+	}
+
+	return nil, false
+}
+
+func findSingleItem(output interface{}) (item map[string]*dynamodb.AttributeValue, isSingle bool) {
+	v := reflect.Indirect(reflect.ValueOf(output))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for _, name := range []string{"Item", "Attributes"} {
+		field := v.FieldByName(name)
+		if field.IsValid() && !field.IsNil() {
+			if item, isItem := field.Interface().(map[string]*dynamodb.AttributeValue); isItem {
+				return item, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// AWS types are written as DynamoDB JSON, in the same way the AWS CLI displays them. Anything else
+// goes through encoding/json as it is.
+func writeJson(w io.Writer, v interface{}, indent bool) error {
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() == reflect.Struct {
+		v = toAwsJson(reflect.ValueOf(v))
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Converts a value of an AWS type to one which encoding/json writes as the AWS JSON protocol does:
+// unset fields are left out, fields are named by their locationName, and timestamps are seconds
+// since the epoch. Binary values are base64 encoded by encoding/json itself.
+func toAwsJson(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toAwsJson(v.Elem())
+	case reflect.Struct:
+		if t, isTime := v.Interface().(time.Time); isTime {
+			return json.Number(strconv.FormatFloat(float64(t.UnixNano()/int64(time.Millisecond))/1000, 'f', -1, 64))
+		}
+		return toAwsJsonObject(v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 || v.IsNil() {
+			return v.Interface()
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = toAwsJson(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			m[key.String()] = toAwsJson(v.MapIndex(key))
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
+
+// A struct's fields, which are written in the order they're declared in
+type jsonObject struct {
+	names  []string
+	values []interface{}
+}
+
+func toAwsJsonObject(v reflect.Value) jsonObject {
+	object := jsonObject{}
+
+	for i := 0; i < v.NumField(); i++ {
+		field, member := v.Type().Field(i), v.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("location") != "" || field.Tag.Get("ignore") != "" || field.Tag.Get("json") == "-" {
+			continue // unexported fields, and ones which aren't part of the request or response body
+		}
+		if (member.Kind() == reflect.Ptr || member.Kind() == reflect.Slice || member.Kind() == reflect.Map) && member.IsNil() {
+			continue
+		}
+
+		name := field.Name
+		if locationName := field.Tag.Get("locationName"); locationName != "" {
+			name = locationName
+		}
+
+		object.names = append(object.names, name)
+		object.values = append(object.values, toAwsJson(member))
+	}
+
+	return object
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, name := range o.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(name); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encoder.Encode(o.values[i]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func toPlainItem(item map[string]*dynamodb.AttributeValue, binary string) map[string]interface{} {
+	plain := make(map[string]interface{})
+	for k, v := range item {
+		plain[k] = toPlainValue(v, binary)
+	}
+
+	return plain
+}
+
+// Converts an attribute value to a value that encoding/json marshals as plain JSON. Numbers are kept
+// as json.Number, so that they don't lose precision. Binary values become strings in the binary
+// encoding.
+func toPlainValue(value *dynamodb.AttributeValue, binary string) interface{} {
+	switch {
+	case value == nil:
+		return nil
+	case value.S != nil:
+		return *value.S
+	case value.N != nil:
+		return json.Number(*value.N)
+	case value.BOOL != nil:
+		return *value.BOOL
+	case value.NULL != nil:
+		return nil
+	case value.B != nil:
+		return encodeBinary(value.B, binary)
+	case value.SS != nil:
+		strs := []string{}
+		for _, s := range value.SS {
+			strs = append(strs, *s)
+		}
+		return strs
+	case value.NS != nil:
+		nums := []json.Number{}
+		for _, n := range value.NS {
+			nums = append(nums, json.Number(*n))
+		}
+		return nums
+	case value.BS != nil:
+		bins := []string{}
+		for _, b := range value.BS {
+			bins = append(bins, encodeBinary(b, binary))
+		}
+		return bins
+	case value.L != nil:
+		list := []interface{}{}
+		for _, v := range value.L {
+			list = append(list, toPlainValue(v, binary))
+		}
+		return list
+	case value.M != nil:
+		return toPlainItem(value.M, binary)
+	default:
+		return nil
+	}
+}
+
+func writeCsv(w io.Writer, items []map[string]*dynamodb.AttributeValue, keyAttributes []string, binary string) error {
+	columns := inferColumns(items, keyAttributes)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write(columns)
+
+	for _, item := range items {
+		row := []string{}
+		for _, column := range columns {
+			row = append(row, formatCell(item[column], binary))
+		}
+		csvWriter.Write(row)
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeTable(w io.Writer, items []map[string]*dynamodb.AttributeValue, keyAttributes []string, binary string) error {
+	columns := inferColumns(items, keyAttributes)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	separators := []string{}
+	for _, column := range columns {
+		separators = append(separators, strings.Repeat("-", len(column)))
+	}
+
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	fmt.Fprintln(tw, strings.Join(separators, "\t"))
+
+	cellEscaper := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for _, item := range items {
+		row := []string{}
+		for _, column := range columns {
+			row = append(row, cellEscaper.Replace(formatCell(item[column], binary)))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// Columns are all top level attributes across all items, with key attributes first and the rest in
+// alphabetical order
+func inferColumns(items []map[string]*dynamodb.AttributeValue, keyAttributes []string) []string {
+	names := map[string]bool{}
+	for _, item := range items {
+		for name := range item {
+			names[name] = true
+		}
+	}
+
+	columns := []string{}
+	for _, key := range keyAttributes {
+		if names[key] {
+			columns = append(columns, key)
+			delete(names, key)
+		}
+	}
+
+	others := []string{}
+	for name := range names {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+
+	return append(columns, others...)
+}
+
+// Scalars are written as they are, binary values encoded, and complex values in the expression value
+// syntax
+func formatCell(value *dynamodb.AttributeValue, binary string) string {
+	switch {
+	case value == nil || value.NULL != nil:
+		return ""
+	case value.S != nil:
+		return *value.S
+	case value.N != nil:
+		return *value.N
+	case value.BOOL != nil:
+		return fmt.Sprintf("%t", *value.BOOL)
+	case value.B != nil:
+		return encodeBinary(value.B, binary)
+	default:
+		return formatValue(value, binary)
+	}
+}
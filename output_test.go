@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func queryOutput() *dynamodb.QueryOutput {
+	count := int64(2)
+
+	return &dynamodb.QueryOutput{
+		Count: &count,
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"pk": str("a"), "sk": integer(1), "name": str("first, item")},
+			{"pk": str("b"), "sk": float(2.5), "tags": stringSet([]string{"x"})},
+		},
+	}
+}
+
+func Test_output_json(t *testing.T) {
+	// given
+	expected := `[
+  {
+    "name": "first, item",
+    "pk": "a",
+    "sk": 1
+  },
+  {
+    "pk": "b",
+    "sk": 2.5,
+    "tags": [
+      "x"
+    ]
+  }
+]
+`
+	var buf bytes.Buffer
+
+	// when
+	err := writeOutput(&buf, outputJson, binaryBase64, queryOutput(), []string{"pk", "sk"})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, expected, buf.String())
+}
+
+func Test_output_jsonSingleItem(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeOutput(&buf, outputJson, binaryBase64, &dynamodb.PutItemOutput{Attributes: map[string]*dynamodb.AttributeValue{"pk": str("a")}}, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"pk\": \"a\"\n}\n", buf.String())
+}
+
+func Test_output_jsonLines(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeOutput(&buf, outputJsonLines, binaryBase64, queryOutput(), []string{"pk", "sk"})
+
+	require.NoError(t, err)
+	require.Equal(t, "{\"name\":\"first, item\",\"pk\":\"a\",\"sk\":1}\n{\"pk\":\"b\",\"sk\":2.5,\"tags\":[\"x\"]}\n", buf.String())
+}
+
+func Test_output_binary(t *testing.T) {
+	var buf bytes.Buffer
+	output := &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{
+		"b":  {B: []byte("Hi")},
+		"bs": {BS: [][]byte{{1}}},
+	}}
+
+	err := writeOutput(&buf, outputJsonLines, binaryHex, output, nil)
+	require.NoError(t, err)
+	require.Equal(t, "{\"b\":\"4869\",\"bs\":[\"01\"]}\n", buf.String())
+
+	buf.Reset()
+	err = writeOutput(&buf, outputCsv, binaryBase64, output, nil)
+	require.NoError(t, err)
+	require.Equal(t, "b,bs\nSGk=,<<b64'AQ=='>>\n", buf.String())
+}
+
+func Test_output_dynamoJson(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeOutput(&buf, outputDynamoJson, binaryBase64, &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{"pk": str("a")}}, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"Item\": {\n    \"pk\": {\n      \"S\": \"a\"\n    }\n  }\n}\n", buf.String())
+}
+
+func Test_output_dynamoJsonDescription(t *testing.T) {
+	var buf bytes.Buffer
+	created := time.Unix(1625140800, 123000000)
+	output := &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+		TableName:        aws.String("Orders<1>"),
+		CreationDateTime: &created,
+		ItemCount:        aws.Int64(0),
+		KeySchema:        []*dynamodb.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")}},
+	}}
+
+	err := writeOutput(&buf, outputJson, binaryBase64, output, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "Table": {
+    "CreationDateTime": 1625140800.123,
+    "ItemCount": 0,
+    "KeySchema": [
+      {
+        "AttributeName": "pk",
+        "KeyType": "HASH"
+      }
+    ],
+    "TableName": "Orders<1>"
+  }
+}
+`, buf.String())
+}
+
+func Test_output_csv(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeOutput(&buf, outputCsv, binaryBase64, queryOutput(), []string{"pk", "sk"})
+
+	require.NoError(t, err)
+	require.Equal(t, "pk,sk,name,tags\na,1,\"first, item\",\nb,2.5,,<<'x'>>\n", buf.String())
+}
+
+func Test_output_table(t *testing.T) {
+	// given
+	expected := "" +
+		"pk  sk   name         tags\n" +
+		"--  --   ----         ----\n" +
+		"a   1    first, item  \n" +
+		"b   2.5               <<'x'>>\n"
+	var buf bytes.Buffer
+
+	// when
+	err := writeOutput(&buf, outputTable, binaryBase64, queryOutput(), []string{"pk", "sk"})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, expected, buf.String())
+}
+
+func Test_output_nonItemOutputs(t *testing.T) {
+	count := int64(5)
+	countOutput := &dynamodb.ScanOutput{Count: &count}
+
+	var buf bytes.Buffer
+	err := writeOutput(&buf, outputJson, binaryBase64, countOutput, nil)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"Count\": 5\n}\n", buf.String())
+
+	buf.Reset()
+	err = writeOutput(&buf, outputCsv, binaryBase64, countOutput, nil)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  Count: 5\n}\n", buf.String())
+}
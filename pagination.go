@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Reads all pages of a query, following LastEvaluatedKey until either there are no more pages or
+// maxItems have been returned. The page limit is lowered to the remaining item count, so that when
+// the limit is hit, the returned LastEvaluatedKey points exactly past the last returned item.
+func queryPages(dynamo *dynamodb.DynamoDB, input *dynamodb.QueryInput, maxItems *int64) (*dynamodb.QueryOutput, error) {
+	var count, scannedCount int64
+	result := &dynamodb.QueryOutput{}
+
+	for {
+		if maxItems != nil {
+			input.SetLimit(*maxItems - count)
+		}
+
+		page, err := dynamo.Query(input)
+		if err != nil {
+			return nil, err
+		}
+
+		count += *page.Count
+		scannedCount += *page.ScannedCount
+
+		if result.Items == nil {
+			result.Items = page.Items
+		} else {
+			result.Items = append(result.Items, page.Items...)
+		}
+		result.ConsumedCapacity = addConsumedCapacity(result.ConsumedCapacity, page.ConsumedCapacity)
+		result.LastEvaluatedKey = page.LastEvaluatedKey
+
+		if page.LastEvaluatedKey == nil || (maxItems != nil && count >= *maxItems) {
+			break
+		}
+
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+
+	result.SetCount(count)
+	result.SetScannedCount(scannedCount)
+
+	return result, nil
+}
+
+// Same as queryPages, but for scans
+func scanPages(dynamo *dynamodb.DynamoDB, input *dynamodb.ScanInput, maxItems *int64) (*dynamodb.ScanOutput, error) {
+	var count, scannedCount int64
+	result := &dynamodb.ScanOutput{}
+
+	for {
+		if maxItems != nil {
+			input.SetLimit(*maxItems - count)
+		}
+
+		page, err := dynamo.Scan(input)
+		if err != nil {
+			return nil, err
+		}
+
+		count += *page.Count
+		scannedCount += *page.ScannedCount
+
+		if result.Items == nil {
+			result.Items = page.Items
+		} else {
+			result.Items = append(result.Items, page.Items...)
+		}
+		result.ConsumedCapacity = addConsumedCapacity(result.ConsumedCapacity, page.ConsumedCapacity)
+		result.LastEvaluatedKey = page.LastEvaluatedKey
+
+		if page.LastEvaluatedKey == nil || (maxItems != nil && count >= *maxItems) {
+			break
+		}
+
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+
+	result.SetCount(count)
+	result.SetScannedCount(scannedCount)
+
+	return result, nil
+}
+
+// Reads all pages of a PartiQL statement, following NextToken until either there are no more pages
+// or at least maxItems have been returned. Statements have no page limit, so the last page is
+// returned whole.
+func statementPages(dynamo *dynamodb.DynamoDB, input *dynamodb.ExecuteStatementInput, maxItems *int64) (*dynamodb.ExecuteStatementOutput, error) {
+	var count int64
+	result := &dynamodb.ExecuteStatementOutput{}
+
+	for {
+		page, err := dynamo.ExecuteStatement(input)
+		if err != nil {
+			return nil, err
+		}
+
+		count += int64(len(page.Items))
+
+		if result.Items == nil {
+			result.Items = page.Items
+		} else {
+			result.Items = append(result.Items, page.Items...)
+		}
+		result.NextToken = page.NextToken
+
+		if page.NextToken == nil || (maxItems != nil && count >= *maxItems) {
+			break
+		}
+
+		input.NextToken = page.NextToken
+	}
+
+	return result, nil
+}
+
+// Sums the consumed capacity of two pages. Either argument may be nil.
+func addConsumedCapacity(total *dynamodb.ConsumedCapacity, page *dynamodb.ConsumedCapacity) *dynamodb.ConsumedCapacity {
+	if page == nil {
+		return total
+	}
+	if total == nil {
+		return page
+	}
+
+	return &dynamodb.ConsumedCapacity{
+		TableName:              total.TableName,
+		CapacityUnits:          addUnits(total.CapacityUnits, page.CapacityUnits),
+		ReadCapacityUnits:      addUnits(total.ReadCapacityUnits, page.ReadCapacityUnits),
+		WriteCapacityUnits:     addUnits(total.WriteCapacityUnits, page.WriteCapacityUnits),
+		Table:                  addCapacity(total.Table, page.Table),
+		GlobalSecondaryIndexes: addIndexCapacity(total.GlobalSecondaryIndexes, page.GlobalSecondaryIndexes),
+		LocalSecondaryIndexes:  addIndexCapacity(total.LocalSecondaryIndexes, page.LocalSecondaryIndexes),
+	}
+}
+
+func addCapacity(total *dynamodb.Capacity, page *dynamodb.Capacity) *dynamodb.Capacity {
+	if page == nil {
+		return total
+	}
+	if total == nil {
+		return page
+	}
+
+	return &dynamodb.Capacity{
+		CapacityUnits:      addUnits(total.CapacityUnits, page.CapacityUnits),
+		ReadCapacityUnits:  addUnits(total.ReadCapacityUnits, page.ReadCapacityUnits),
+		WriteCapacityUnits: addUnits(total.WriteCapacityUnits, page.WriteCapacityUnits),
+	}
+}
+
+func addIndexCapacity(total map[string]*dynamodb.Capacity, page map[string]*dynamodb.Capacity) map[string]*dynamodb.Capacity {
+	if page == nil {
+		return total
+	}
+	if total == nil {
+		return page
+	}
+
+	result := make(map[string]*dynamodb.Capacity)
+	for index, capacity := range total {
+		result[index] = capacity
+	}
+	for index, capacity := range page {
+		result[index] = addCapacity(result[index], capacity)
+	}
+
+	return result
+}
+
+func addUnits(a *float64, b *float64) *float64 {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+
+	sum := *a + *b
+	return &sum
+}
+
+// Starting tokens are {"ExclusiveStartKey": <LastEvaluatedKey>} in DynamoDB JSON, base64 encoded so
+// that they can be passed as a single argument
+func encodeStartingToken(key map[string]*dynamodb.AttributeValue) (string, error) {
+	var keyJson bytes.Buffer
+	if err := writeJson(&keyJson, &dynamodb.ScanInput{ExclusiveStartKey: key}, false); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(bytes.TrimRight(keyJson.Bytes(), "\n")), nil
+}
+
+// The fields of AttributeValue are named as in DynamoDB JSON, so encoding/json can read it back
+func decodeStartingToken(token string) (map[string]*dynamodb.AttributeValue, error) {
+	keyJson, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, newValidationError("Invalid starting token: %s", token)
+	}
+
+	decoded := struct {
+		ExclusiveStartKey map[string]*dynamodb.AttributeValue
+	}{}
+	err = json.Unmarshal(keyJson, &decoded)
+	if err != nil || len(decoded.ExclusiveStartKey) == 0 {
+		return nil, newValidationError("Invalid starting token: %s", token)
+	}
+
+	return decoded.ExclusiveStartKey, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_startingToken_roundTrip(t *testing.T) {
+	// given
+	key := map[string]*dynamodb.AttributeValue{
+		"pk":  str("someStr"),
+		"sk":  integer(123),
+		"bin": {B: []byte{1, 2, 255}},
+		"set": {SS: []*string{aws.String("a"), aws.String("b")}},
+		"map": {M: map[string]*dynamodb.AttributeValue{"ok": {BOOL: aws.Bool(true)}, "none": {NULL: aws.Bool(true)}}},
+	}
+
+	// when
+	token, err := encodeStartingToken(key)
+	require.NoError(t, err)
+	decoded, err := decodeStartingToken(token)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, key, decoded)
+}
+
+func Test_startingToken_invalid(t *testing.T) {
+	_, err := decodeStartingToken("not a token")
+	require.Error(t, err)
+
+	_, err = decodeStartingToken("e30=") // {}
+	require.Error(t, err)
+}
+
+func Test_addConsumedCapacity(t *testing.T) {
+	one, two := 1.0, 2.0
+
+	require.Nil(t, addConsumedCapacity(nil, nil))
+
+	total := addConsumedCapacity(&dynamodb.ConsumedCapacity{CapacityUnits: &one}, &dynamodb.ConsumedCapacity{CapacityUnits: &two})
+	require.Equal(t, 3.0, *total.CapacityUnits)
+}
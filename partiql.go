@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const maxBatchStatements = 25
+
+type sqlOpts struct {
+	Params         string `long:"params" description:"List of values for the ? parameters of the statements" required:"false"`
+	ConsistentRead bool   `short:"c" long:"consistent-read" description:"Set consistent-read to true" required:"false"`
+	Limit          *int64 `short:"l" long:"limit" description:"Stop reading pages once this many items have been returned" required:"false"`
+	StartingToken  string `short:"t" long:"starting-token" description:"NextToken from a previous statement to continue from" required:"false"`
+}
+
+// A PartiQL statement, and the number of ? parameters in it
+type statement struct {
+	text   string
+	params int
+}
+
+// Runs PartiQL statements. Flags come first, the rest of the line is the statement, e.g.
+// sql --params "['a']" SELECT * FROM "Music" WHERE Artist = ?
+// Several statements separated by ';' are sent as a single BatchExecuteStatement request.
+func (e executor) handleSql(args string) error {
+	flagArgs, sql := splitLeadingFlags(args, &sqlOpts{})
+
+	sqlOpts := sqlOpts{}
+
+	if proceed, err := parseFlags(&sqlOpts, flagArgs); !proceed {
+		return err
+	}
+
+	if err := validateLimit(sqlOpts.Limit); err != nil {
+		return err
+	}
+
+	statements, err := splitStatements(sql)
+	if err != nil {
+		return err
+	}
+
+	if len(statements) == 0 {
+		return newValidationError("No statement given")
+	}
+
+	params, err := parseParams(sqlOpts.Params, statements)
+	if err != nil {
+		return withFlag(err, "--params")
+	}
+
+	if len(statements) == 1 {
+		input := dynamodb.ExecuteStatementInput{Statement: &statements[0].text, Parameters: params[0]}
+
+		if sqlOpts.ConsistentRead {
+			input.SetConsistentRead(true)
+		}
+		if sqlOpts.StartingToken != "" {
+			input.SetNextToken(sqlOpts.StartingToken)
+		}
+
+		return e.runStatement(&input, sqlOpts.Limit)
+	}
+
+	if sqlOpts.Limit != nil || sqlOpts.StartingToken != "" {
+		return newValidationError("--limit and --starting-token can only be used with a single statement")
+	}
+	if len(statements) > maxBatchStatements {
+		return newValidationError("Batches can't contain more than %d statements", maxBatchStatements)
+	}
+
+	input := dynamodb.BatchExecuteStatementInput{}
+	for i := range statements {
+		request := &dynamodb.BatchStatementRequest{Statement: &statements[i].text, Parameters: params[i]}
+		if sqlOpts.ConsistentRead {
+			request.SetConsistentRead(true)
+		}
+		input.Statements = append(input.Statements, request)
+	}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	output, err := e.dynamo.BatchExecuteStatement(&input)
+	if err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	e.state.lastRead = nil
+
+	if err := e.printOutput(output); err != nil {
+		return err
+	}
+
+	return batchStatementsError(output, input.String())
+}
+
+// Statements of a batch fail separately, so that the others still run. The batch fails when any of
+// them has, with the error of the first.
+func batchStatementsError(output *dynamodb.BatchExecuteStatementOutput, input string) error {
+	failed := []int{}
+	for i, response := range output.Responses {
+		if response.Error != nil {
+			failed = append(failed, i)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	first := output.Responses[failed[0]].Error
+	message := fmt.Sprintf("%d of %d statements failed, the first was statement %d: %s",
+		len(failed), len(output.Responses), failed[0]+1, aws.StringValue(first.Message))
+
+	return newAwsError(awserr.New(aws.StringValue(first.Code), message, nil), input)
+}
+
+func (e executor) runStatement(input *dynamodb.ExecuteStatementInput, limit *int64) error {
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	output, err := statementPages(e.dynamo, input, limit)
+	if err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	nextToken := output.NextToken
+	output.NextToken = nil
+
+	e.state.lastRead = &pagedRead{statement: input, limit: limit, nextToken: nextToken}
+
+	if err := e.printOutput(output); err != nil {
+		return err
+	}
+
+	if nextToken != nil {
+		e.printInfo("NextToken: " + *nextToken)
+	}
+
+	return nil
+}
+
+// Splits the leading flags off a command line, so that the rest can be used as is, without being
+// split into arguments. Flags end at the first argument which doesn't start with '-' and isn't the
+// value of a flag.
+func splitLeadingFlags(args string, opts interface{}) (flagArgs string, rest string) {
+	boolFlags := map[string]bool{"-h": true, "--help": true}
+
+	optsType := reflect.TypeOf(opts).Elem()
+	for i := 0; i < optsType.NumField(); i++ {
+		field := optsType.Field(i)
+		if field.Type.Kind() == reflect.Bool {
+			boolFlags["-"+field.Tag.Get("short")] = true
+			boolFlags["--"+field.Tag.Get("long")] = true
+		}
+	}
+
+	pos := 0
+	expectValue := false
+
+	for {
+		for pos < len(args) && args[pos] == ' ' {
+			pos++
+		}
+		if pos == len(args) || (!expectValue && args[pos] != '-') {
+			break
+		}
+
+		end := argumentEnd(args, pos)
+		arg := args[pos:end]
+		pos = end
+
+		if expectValue {
+			expectValue = false
+		} else if arg == "--" {
+			break
+		} else if !strings.Contains(arg, "=") && !boolFlags[arg] {
+			expectValue = true
+		}
+	}
+
+	return strings.TrimSpace(args[:pos]), strings.TrimSpace(args[pos:])
+}
+
+// The end of the argument starting at pos, which is either double quoted or ends at a space
+func argumentEnd(args string, pos int) int {
+	if args[pos] == '"' {
+		for i := pos + 1; i < len(args); i++ {
+			if args[i] == '"' && args[i-1] != '\\' {
+				return i + 1
+			}
+		}
+		return len(args)
+	}
+
+	if end := strings.IndexByte(args[pos:], ' '); end != -1 {
+		return pos + end
+	}
+	return len(args)
+}
+
+// Splits statements by ';', and counts the ? parameters in each. Separators and question marks in
+// strings ('...') and quoted names ("...") are ignored. Empty statements are skipped.
+func splitStatements(sql string) ([]statement, error) {
+	statements := []statement{}
+	start, params := 0, 0
+	quote, quoteStart := byte(0), 0
+
+	add := func(end int) {
+		if text := strings.TrimSpace(sql[start:end]); text != "" {
+			statements = append(statements, statement{text: text, params: params})
+		}
+		start, params = end+1, 0
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				// quotes are escaped by doubling them
+				if i+1 < len(sql) && sql[i+1] == quote {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '\'' || c == '"':
+			quote, quoteStart = c, i
+		case c == '?':
+			params++
+		case c == ';':
+			add(i)
+		}
+	}
+
+	if quote != 0 {
+		return nil, newParseErrorAt(sql, quoteStart, "Unterminated %s", map[byte]string{'\'': "string", '"': "name"}[quote])
+	}
+	add(len(sql))
+
+	return statements, nil
+}
+
+// Parses the parameters as a list of values, in the same syntax as other values, and assigns them
+// to the statements in order
+func parseParams(expr string, statements []statement) ([][]*dynamodb.AttributeValue, error) {
+	var values []*dynamodb.AttributeValue
+
+	if expr != "" {
+		list, err := parseValue(expr)
+		if err != nil {
+			return nil, err
+		}
+		if list.L == nil {
+			return nil, newValidationError("Expected a list of parameters")
+		}
+		values = list.L
+	}
+
+	total := 0
+	for _, statement := range statements {
+		total += statement.params
+	}
+	if total != len(values) {
+		return nil, newValidationError("Expected %d parameters, got %d", total, len(values))
+	}
+
+	result := [][]*dynamodb.AttributeValue{}
+	for _, statement := range statements {
+		if statement.params == 0 {
+			result = append(result, nil)
+		} else {
+			result = append(result, values[:statement.params])
+		}
+		values = values[statement.params:]
+	}
+
+	return result, nil
+}
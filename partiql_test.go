@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitLeadingFlags(t *testing.T) {
+	for _, test := range []struct {
+		args      string
+		flagArgs  string
+		statement string
+	}{
+		{`SELECT * FROM "Music"`, ``, `SELECT * FROM "Music"`},
+		{`-c --params "['a', 'b c']" SELECT * FROM Music WHERE a = ?`, `-c --params "['a', 'b c']"`, `SELECT * FROM Music WHERE a = ?`},
+		{`-l 10 -t=abc  SELECT a-b FROM Music`, `-l 10 -t=abc`, `SELECT a-b FROM Music`},
+		{`-c -- -x`, `-c --`, `-x`},
+		{`--params`, `--params`, ``},
+	} {
+		// when
+		flagArgs, statement := splitLeadingFlags(test.args, &sqlOpts{})
+
+		// then
+		require.Equal(t, test.flagArgs, flagArgs, test.args)
+		require.Equal(t, test.statement, statement, test.args)
+	}
+}
+
+func Test_splitStatements(t *testing.T) {
+	// given
+	sql := ` SELECT * FROM "a;?" WHERE b = ? ; ; UPDATE t SET x = 'it''s; ?' WHERE pk = ? AND sk = ?;`
+
+	// when
+	statements, err := splitStatements(sql)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []statement{
+		{text: `SELECT * FROM "a;?" WHERE b = ?`, params: 1},
+		{text: `UPDATE t SET x = 'it''s; ?' WHERE pk = ? AND sk = ?`, params: 2},
+	}, statements)
+}
+
+func Test_splitStatements_unterminated(t *testing.T) {
+	_, err := splitStatements(`SELECT * FROM t WHERE a = 'b`)
+
+	require.EqualError(t, err, "Unterminated string\n  SELECT * FROM t WHERE a = 'b\n                            ^")
+}
+
+func Test_parseParams(t *testing.T) {
+	// given
+	statements := []statement{{params: 1}, {params: 0}, {params: 2}}
+
+	// when
+	params, err := parseParams("['a', 1, true]", statements)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, [][]*dynamodb.AttributeValue{
+		{{S: aws.String("a")}},
+		nil,
+		{{N: aws.String("1")}, {BOOL: aws.Bool(true)}},
+	}, params)
+}
+
+func Test_parseParams_errors(t *testing.T) {
+	_, err := parseParams("['a']", []statement{{params: 2}})
+	require.EqualError(t, err, "Expected 2 parameters, got 1")
+
+	_, err = parseParams("{ a: 1 }", []statement{{params: 1}})
+	require.EqualError(t, err, "Expected a list of parameters")
+
+	_, err = parseParams("", []statement{{params: 0}})
+	require.NoError(t, err)
+}
+
+func Test_sql_batchFailsWithStatement(t *testing.T) {
+	// given
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		return &dynamodb.BatchExecuteStatementOutput{Responses: []*dynamodb.BatchStatementResponse{
+			{TableName: aws.String("Orders")},
+			{Error: &dynamodb.BatchStatementError{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("Item exists")}},
+		}}
+	})
+	e := newExecutor(dynamo, &tableContext{}, outputJsonLines, false)
+
+	// when
+	var err error
+	captureStdout(t, func() {
+		err = e.run(`sql INSERT INTO "Orders" VALUE {'pk': 'a'}; INSERT INTO "Orders" VALUE {'pk': 'b'}`)
+	})
+
+	// then
+	require.EqualError(t, err, "ConditionalCheckFailed: 1 of 2 statements failed, the first was statement 2: Item exists")
+	require.Equal(t, exitAws, exitCode(err))
+}
+
+func Test_batchStatementsError_noErrors(t *testing.T) {
+	output := &dynamodb.BatchExecuteStatementOutput{Responses: []*dynamodb.BatchStatementResponse{{}, {}}}
+
+	require.NoError(t, batchStatementsError(output, ""))
+}
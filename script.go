@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Runs commands non-interactively, stopping at the first command that fails.
+// Returns the exit code for the process.
+func (e executor) runScript(script string) int {
+	for _, command := range splitCommands(script) {
+		if err := e.run(command); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCode(err)
+		}
+	}
+
+	if e.state.transaction != nil {
+		err := newValidationError("Transaction was not committed, %d operations discarded", len(e.state.transaction.items))
+		fmt.Fprintln(os.Stderr, err)
+		return exitCode(err)
+	}
+
+	return exitOk
+}
+
+// Splits a script into commands. Commands are separated by newlines or ';', unless they're in a
+// quoted argument or string value. Lines starting with '#' are comments. sql commands run to the end
+// of the line, since ';' separates their statements.
+func splitCommands(script string) []string {
+	var commands []string
+	var current strings.Builder
+	var quote rune = 0
+	isEscaped := false
+	isComment := false
+
+	endCommand := func() {
+		command := strings.TrimSpace(current.String())
+		if command != "" {
+			commands = append(commands, command)
+		}
+		current.Reset()
+	}
+
+	for _, char := range script {
+		switch {
+		case isComment:
+			if char == '\n' {
+				isComment = false
+			}
+			continue
+		case isEscaped:
+			isEscaped = false
+		case char == '\\':
+			isEscaped = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '#' && strings.TrimSpace(current.String()) == "":
+			isComment = true
+			continue
+		case char == ';' && isSqlCommand(current.String()):
+		case char == ';' || char == '\n':
+			endCommand()
+			continue
+		}
+
+		current.WriteRune(char)
+	}
+
+	endCommand()
+
+	return commands
+}
+
+func isSqlCommand(command string) bool {
+	name := strings.Fields(command)
+	return len(name) > 0 && (name[0] == "sql" || name[0] == "partiql")
+}
+
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_script_splitCommands(t *testing.T) {
+	script := `
+# comment; not a command
+use Orders; query -k "pk = 'a;b'"
+put -i "{ pk: 'It\'s; \"quoted\"' }"
+
+  scan`
+
+	commands := splitCommands(script)
+
+	require.Equal(t, []string{
+		"use Orders",
+		`query -k "pk = 'a;b'"`,
+		`put -i "{ pk: 'It\'s; \"quoted\"' }"`,
+		"scan",
+	}, commands)
+}
+
+func Test_script_splitCommands_empty(t *testing.T) {
+	require.Nil(t, splitCommands(" ;\n; # only a comment"))
+}
+
+func Test_script_splitCommands_sql(t *testing.T) {
+	commands := splitCommands("use Orders; sql SELECT * FROM Orders; DELETE FROM Orders WHERE pk = 'a'\nscan")
+
+	require.Equal(t, []string{
+		"use Orders",
+		"sql SELECT * FROM Orders; DELETE FROM Orders WHERE pk = 'a'",
+		"scan",
+	}, commands)
+}
@@ -0,0 +1,706 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const createTableUsage = `Usage:
+  create-table NAME HASH_KEY [RANGE_KEY] [OPTIONS]
+
+Keys are written as name:type, where type is S, N or B, e.g. create-table Orders pk:S sk:S
+
+Options:
+  --gsi NAME HASH_KEY [RANGE_KEY]  Add a global secondary index, can be repeated
+  --lsi NAME RANGE_KEY             Add a local secondary index, can be repeated
+  --billing on-demand|provisioned  Billing mode, on-demand by default
+  --read UNITS                     Provisioned read capacity, for the table and its GSIs (default 5)
+  --write UNITS                    Provisioned write capacity, for the table and its GSIs (default 5)
+  --no-wait                        Don't wait for the table to become active`
+
+const updateTableUsage = `Usage:
+  update-table [NAME] [OPTIONS]
+
+Updates the current table, or the given one.
+
+Options:
+  --billing on-demand|provisioned  Change the billing mode
+  --read UNITS                     Provisioned read capacity (5 when switching to provisioned)
+  --write UNITS                    Provisioned write capacity (5 when switching to provisioned)
+  --add-gsi NAME HASH_KEY [RANGE]  Add a global secondary index, with the table's capacity when provisioned
+  --delete-gsi NAME                Delete a global secondary index
+  --stream TYPE|disabled           Enable the stream with a view type, e.g. new-and-old-images, or disable it
+  --no-wait                        Don't wait for the table to become active`
+
+const (
+	billingOnDemand    = "on-demand"
+	billingProvisioned = "provisioned"
+)
+
+var tablePollInterval = 2 * time.Second
+
+var billingFlag = flag{long: "billing", desc: "Billing mode"}
+
+// Flags of create-table, for completion
+var createTableFlags = []flag{
+	{long: "gsi", desc: "Add a global secondary index"},
+	{long: "lsi", desc: "Add a local secondary index"},
+	billingFlag,
+	{long: "read", desc: "Provisioned read capacity"},
+	{long: "write", desc: "Provisioned write capacity"},
+	{long: "no-wait", desc: "Don't wait for the table to become active"},
+}
+
+var streamFlag = flag{long: "stream", desc: "Stream view type, or disabled"}
+
+// Flags of update-table, for completion
+var updateTableFlags = []flag{
+	billingFlag,
+	{long: "read", desc: "Provisioned read capacity"},
+	{long: "write", desc: "Provisioned write capacity"},
+	{long: "add-gsi", desc: "Add a global secondary index"},
+	{long: "delete-gsi", desc: "Delete a global secondary index"},
+	streamFlag,
+	{long: "no-wait", desc: "Don't wait for the table to become active"},
+}
+
+var streamViewTypes = []string{"new-image", "old-image", "new-and-old-images", "keys-only", "disabled"}
+
+type deleteTableOpts struct {
+	Yes    bool `short:"y" long:"yes" description:"Delete without asking to retype the table name" required:"false"`
+	NoWait bool `long:"no-wait" description:"Don't wait for the table to be deleted" required:"false"`
+}
+
+// Creates a table, e.g. create-table Orders pk:S sk:S --gsi byCustomer customerId:S createdAt:N.
+// Indexes take a variable number of keys, so flags are parsed here instead of with go-flags.
+func (e executor) handleCreateTable(args string) error {
+	parsedArgs := parseArgs(args)
+	if contains(parsedArgs, "-h") || contains(parsedArgs, "--help") {
+		fmt.Println(createTableUsage)
+		return nil
+	}
+
+	input, wait, err := parseCreateTable(parsedArgs)
+	if err != nil {
+		return err
+	}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	if _, err := e.dynamo.CreateTable(input); err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	if wait {
+		if err := e.waitForTable(*input.TableName); err != nil {
+			return err
+		}
+	}
+
+	if err := e.refreshTables(); err != nil {
+		return err
+	}
+
+	if wait {
+		e.printInfo(fmt.Sprintf("Created table %s", *input.TableName))
+	} else {
+		e.printInfo(fmt.Sprintf("Creating table %s", *input.TableName))
+	}
+
+	return nil
+}
+
+func parseCreateTable(args []string) (input *dynamodb.CreateTableInput, wait bool, err error) {
+	input = &dynamodb.CreateTableInput{}
+	wait = true
+	billing := ""
+	var read, write *int64
+
+	positional := []string{}
+	types := map[string]string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "--gsi":
+			gsiArgs, err := flagValues(args, &i, 2, 3)
+			if err != nil {
+				return nil, false, err
+			}
+			keySchema, err := parseKeySchema(gsiArgs[1:], types)
+			if err != nil {
+				return nil, false, err
+			}
+			input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndex{
+				IndexName:  aws.String(gsiArgs[0]),
+				KeySchema:  keySchema,
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			})
+		case "--lsi":
+			lsiArgs, err := flagValues(args, &i, 2, 2)
+			if err != nil {
+				return nil, false, err
+			}
+			rangeKey, err := parseKey(lsiArgs[1], types)
+			if err != nil {
+				return nil, false, err
+			}
+			// the hash key is the table's, which is added once it's known
+			input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndex{
+				IndexName:  aws.String(lsiArgs[0]),
+				KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String(rangeKey), KeyType: aws.String(dynamodb.KeyTypeRange)}},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			})
+		case "--billing":
+			billingArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, false, err
+			}
+			billing = billingArgs[0]
+			if billing != billingOnDemand && billing != billingProvisioned {
+				return nil, false, newValidationError("Billing mode must be %s or %s", billingOnDemand, billingProvisioned)
+			}
+		case "--read", "--write":
+			unitArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, false, err
+			}
+			units, err := strconv.ParseInt(unitArgs[0], 10, 64)
+			if err != nil || units < 1 {
+				return nil, false, newValidationError("%s must be a positive number", arg)
+			}
+			if arg == "--read" {
+				read = &units
+			} else {
+				write = &units
+			}
+		case "--no-wait":
+			wait = false
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return nil, false, newValidationError("unknown flag `%s'", strings.TrimLeft(arg, "-"))
+			}
+			// table keys are parsed as they come, so that conflicting index keys are reported against them
+			if len(positional) > 0 {
+				if _, err := parseKey(arg, types); err != nil {
+					return nil, false, err
+				}
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) < 2 || len(positional) > 3 {
+		return nil, false, newValidationError("Expected a table name and 1 or 2 keys, e.g. create-table Orders pk:S sk:S")
+	}
+
+	input.TableName = aws.String(positional[0])
+	input.KeySchema, err = parseKeySchema(positional[1:], types)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, lsi := range input.LocalSecondaryIndexes {
+		if len(input.KeySchema) == 1 {
+			return nil, false, newValidationError("Local secondary indexes need a table with a range key")
+		}
+		lsi.KeySchema = append([]*dynamodb.KeySchemaElement{input.KeySchema[0]}, lsi.KeySchema...)
+	}
+
+	names := []string{}
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: aws.String(types[name]),
+		})
+	}
+
+	if billing == "" && (read != nil || write != nil) {
+		billing = billingProvisioned
+	}
+
+	if billing == billingProvisioned {
+		throughput := &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)}
+		if read != nil {
+			throughput.ReadCapacityUnits = read
+		}
+		if write != nil {
+			throughput.WriteCapacityUnits = write
+		}
+
+		input.SetBillingMode(dynamodb.BillingModeProvisioned)
+		input.ProvisionedThroughput = throughput
+		for _, gsi := range input.GlobalSecondaryIndexes {
+			gsi.ProvisionedThroughput = throughput
+		}
+	} else {
+		if read != nil || write != nil {
+			return nil, false, newValidationError("--read and --write can only be used with provisioned billing")
+		}
+		input.SetBillingMode(dynamodb.BillingModePayPerRequest)
+	}
+
+	return input, wait, nil
+}
+
+// Returns the values which follow the flag at args[*i], up to the next flag, and moves i past them
+func flagValues(args []string, i *int, min int, max int) ([]string, error) {
+	start := *i + 1
+	end := start
+	for end < len(args) && end-start < max && !strings.HasPrefix(args[end], "-") {
+		end++
+	}
+	if end-start < min {
+		return nil, newValidationError("Missing values for %s, see --help", args[*i])
+	}
+
+	*i = end - 1
+	return args[start:end], nil
+}
+
+// Parses a hash key and an optional range key
+func parseKeySchema(keys []string, types map[string]string) ([]*dynamodb.KeySchemaElement, error) {
+	keySchema := []*dynamodb.KeySchemaElement{}
+
+	for i, key := range keys {
+		name, err := parseKey(key, types)
+		if err != nil {
+			return nil, err
+		}
+
+		keyType := dynamodb.KeyTypeHash
+		if i == 1 {
+			keyType = dynamodb.KeyTypeRange
+		}
+		keySchema = append(keySchema, &dynamodb.KeySchemaElement{AttributeName: aws.String(name), KeyType: aws.String(keyType)})
+	}
+
+	return keySchema, nil
+}
+
+// Parses a key written as name:type, and adds its type to types
+func parseKey(key string, types map[string]string) (string, error) {
+	separator := strings.LastIndex(key, ":")
+	if separator < 1 {
+		return "", newValidationError("Invalid key %s, expected name:type where type is S, N or B", key)
+	}
+
+	name, keyType := key[:separator], strings.ToUpper(key[separator+1:])
+	if keyType != "S" && keyType != "N" && keyType != "B" {
+		return "", newValidationError("Invalid key %s, expected name:type where type is S, N or B", key)
+	}
+
+	if types[name] != "" && types[name] != keyType {
+		return "", newValidationError("Key %s is used as both %s and %s", name, types[name], keyType)
+	}
+	types[name] = keyType
+
+	return name, nil
+}
+
+// Polls a table until it and its indexes are active, showing their status in the meantime
+func (e executor) waitForTable(name string) error {
+	start := time.Now()
+	lastStatus := ""
+	showProgress := isTerminal(os.Stdout) && e.state.output == outputNative
+
+	for {
+		input := &dynamodb.DescribeTableInput{TableName: &name}
+		output, err := e.dynamo.DescribeTable(input)
+		if err != nil {
+			return newAwsError(err, input.String())
+		}
+
+		status := pendingTableStatus(output.Table)
+		if status == "" {
+			if showProgress && lastStatus != "" {
+				fmt.Println()
+			}
+			return nil
+		}
+
+		if showProgress {
+			fmt.Printf("\rWaiting for %s: %s (%s)\033[K", name, status, time.Since(start).Round(time.Second))
+		} else if status != lastStatus {
+			e.printInfo(fmt.Sprintf("Waiting for %s: %s", name, status))
+		}
+		lastStatus = status
+
+		sleep(tablePollInterval)
+	}
+}
+
+// Describes the parts of a table which aren't active yet, e.g. "updating, index byCustomer creating".
+// Empty when the table and all of its indexes are active. Missing statuses, which some local
+// emulators leave out, are taken as active.
+func pendingTableStatus(table *dynamodb.TableDescription) string {
+	pending := []string{}
+
+	if status := aws.StringValue(table.TableStatus); status != "" && status != dynamodb.TableStatusActive {
+		pending = append(pending, strings.ToLower(status))
+	}
+
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if status := aws.StringValue(gsi.IndexStatus); status != "" && status != dynamodb.IndexStatusActive {
+			pending = append(pending, fmt.Sprintf("index %s %s", aws.StringValue(gsi.IndexName), strings.ToLower(status)))
+		}
+	}
+
+	return strings.Join(pending, ", ")
+}
+
+// Deletes the current table, or the given one. Unless --yes is given, the table name has to be
+// retyped to confirm.
+func (e executor) handleDeleteTable(args string) error {
+	deleteTableOpts := deleteTableOpts{}
+
+	rest, proceed, err := parseFlagsAndArgs(&deleteTableOpts, args)
+	if !proceed {
+		return err
+	}
+
+	name, err := e.tableArgument(rest)
+	if err != nil {
+		return err
+	}
+
+	if !deleteTableOpts.Yes {
+		if !isTerminal(os.Stdin) {
+			return newValidationError("Use --yes to delete tables without confirmation")
+		}
+
+		fmt.Printf("Type the table name to confirm deleting %s: ", name)
+		confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			fmt.Println()
+		}
+		if strings.TrimSpace(confirmation) != name {
+			return newValidationError("Table name didn't match, %s was not deleted", name)
+		}
+	}
+
+	input := &dynamodb.DeleteTableInput{TableName: &name}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	if _, err := e.dynamo.DeleteTable(input); err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	if name == e.tableCtx.name {
+		*e.tableCtx = tableContext{allTables: e.tableCtx.allTables}
+		e.state.lastRead = nil
+	}
+
+	if !deleteTableOpts.NoWait {
+		if err := e.waitForTableDeleted(name); err != nil {
+			return err
+		}
+	}
+
+	if err := e.refreshTables(); err != nil {
+		return err
+	}
+
+	if deleteTableOpts.NoWait {
+		e.printInfo(fmt.Sprintf("Deleting table %s", name))
+	} else {
+		e.printInfo(fmt.Sprintf("Deleted table %s", name))
+	}
+
+	return nil
+}
+
+// Updates billing, capacity, indexes and stream settings of the current table, or the given one
+func (e executor) handleUpdateTable(args string) error {
+	parsedArgs := parseArgs(args)
+	if contains(parsedArgs, "-h") || contains(parsedArgs, "--help") {
+		fmt.Println(updateTableUsage)
+		return nil
+	}
+
+	input, rest, wait, err := parseUpdateTable(parsedArgs)
+	if err != nil {
+		return err
+	}
+
+	name, err := e.tableArgument(rest)
+	if err != nil {
+		return err
+	}
+	input.TableName = &name
+
+	describeInput := &dynamodb.DescribeTableInput{TableName: &name}
+	describeOutput, err := e.dynamo.DescribeTable(describeInput)
+	if err != nil {
+		return newAwsError(err, describeInput.String())
+	}
+
+	completeThroughput(input, describeOutput.Table)
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	if _, err := e.dynamo.UpdateTable(input); err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	if wait {
+		if err := e.waitForTable(name); err != nil {
+			return err
+		}
+	}
+
+	// the indexes of the current table may have changed, even if they're still being created
+	if name == e.tableCtx.name {
+		if err := e.refreshCurrentTable(); err != nil {
+			return err
+		}
+	}
+
+	if !wait {
+		e.printInfo(fmt.Sprintf("Updating table %s", name))
+		return nil
+	}
+
+	e.printInfo(fmt.Sprintf("Updated table %s", name))
+
+	return nil
+}
+
+// Describes the current table again to update its keys and indexes. Unlike use, the attributes
+// learned for completion and the last read are kept, as the table's items haven't changed.
+func (e executor) refreshCurrentTable() error {
+	describeInput := &dynamodb.DescribeTableInput{TableName: aws.String(e.tableCtx.name)}
+	describeOutput, err := e.dynamo.DescribeTable(describeInput)
+	if err != nil {
+		return newAwsError(err, describeInput.String())
+	}
+
+	attributes := e.tableCtx.attributes
+	e.tableCtx.setTable(describeOutput.Table)
+	e.tableCtx.attributes = attributes
+
+	return nil
+}
+
+// The table a command was given, or the current table
+func (e executor) tableArgument(args []string) (string, error) {
+	switch {
+	case len(args) > 1:
+		return "", newValidationError("Expected a single table name")
+	case len(args) == 1:
+		return args[0], nil
+	case e.tableCtx.name == "":
+		return "", newValidationError("No table selected!")
+	default:
+		return e.tableCtx.name, nil
+	}
+}
+
+// Parses the flags of update-table. The table name isn't set, it's returned with the other
+// arguments which aren't flags.
+func parseUpdateTable(args []string) (input *dynamodb.UpdateTableInput, rest []string, wait bool, err error) {
+	input = &dynamodb.UpdateTableInput{}
+	wait = true
+	types := map[string]string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "--billing":
+			billingArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			switch billingArgs[0] {
+			case billingOnDemand:
+				input.SetBillingMode(dynamodb.BillingModePayPerRequest)
+			case billingProvisioned:
+				input.SetBillingMode(dynamodb.BillingModeProvisioned)
+			default:
+				return nil, nil, false, newValidationError("Billing mode must be %s or %s", billingOnDemand, billingProvisioned)
+			}
+		case "--read", "--write":
+			unitArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			units, err := strconv.ParseInt(unitArgs[0], 10, 64)
+			if err != nil || units < 1 {
+				return nil, nil, false, newValidationError("%s must be a positive number", arg)
+			}
+			if input.ProvisionedThroughput == nil {
+				input.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{}
+			}
+			if arg == "--read" {
+				input.ProvisionedThroughput.ReadCapacityUnits = &units
+			} else {
+				input.ProvisionedThroughput.WriteCapacityUnits = &units
+			}
+		case "--add-gsi":
+			gsiArgs, err := flagValues(args, &i, 2, 3)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			keySchema, err := parseKeySchema(gsiArgs[1:], types)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+				Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+					IndexName:  aws.String(gsiArgs[0]),
+					KeySchema:  keySchema,
+					Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+				},
+			})
+		case "--delete-gsi":
+			gsiArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+				Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(gsiArgs[0])},
+			})
+		case "--stream":
+			streamArgs, err := flagValues(args, &i, 1, 1)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			viewType := strings.ToLower(strings.ReplaceAll(streamArgs[0], "_", "-"))
+			if !contains(streamViewTypes, viewType) {
+				return nil, nil, false, newValidationError("Stream must be one of %s", strings.Join(streamViewTypes, ", "))
+			}
+			if viewType == "disabled" {
+				input.StreamSpecification = &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)}
+			} else {
+				input.StreamSpecification = &dynamodb.StreamSpecification{
+					StreamEnabled:  aws.Bool(true),
+					StreamViewType: aws.String(strings.ToUpper(strings.ReplaceAll(viewType, "-", "_"))),
+				}
+			}
+		case "--no-wait":
+			wait = false
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return nil, nil, false, newValidationError("unknown flag `%s'", strings.TrimLeft(arg, "-"))
+			}
+			rest = append(rest, arg)
+		}
+	}
+
+	if len(input.GlobalSecondaryIndexUpdates) > 1 {
+		return nil, nil, false, newValidationError("Only one index can be added or deleted per update")
+	}
+
+	if input.BillingMode == nil && input.ProvisionedThroughput == nil && input.GlobalSecondaryIndexUpdates == nil && input.StreamSpecification == nil {
+		return nil, nil, false, newValidationError("Nothing to update, see update-table --help")
+	}
+
+	for name, attributeType := range types {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: aws.String(attributeType),
+		})
+	}
+	sort.Slice(input.AttributeDefinitions, func(i, j int) bool {
+		return *input.AttributeDefinitions[i].AttributeName < *input.AttributeDefinitions[j].AttributeName
+	})
+
+	return input, rest, wait, nil
+}
+
+// DynamoDB needs both read and write capacity whenever one of them is set, and new indexes of
+// provisioned tables need a capacity too. Whatever wasn't given is taken from the table, or is 5
+// when the table is switching to provisioned billing.
+func completeThroughput(input *dynamodb.UpdateTableInput, table *dynamodb.TableDescription) {
+	current := &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)}
+
+	isProvisioned := table.BillingModeSummary == nil || aws.StringValue(table.BillingModeSummary.BillingMode) == dynamodb.BillingModeProvisioned
+	if isProvisioned && table.ProvisionedThroughput != nil && aws.Int64Value(table.ProvisionedThroughput.ReadCapacityUnits) > 0 {
+		current.ReadCapacityUnits = table.ProvisionedThroughput.ReadCapacityUnits
+		current.WriteCapacityUnits = table.ProvisionedThroughput.WriteCapacityUnits
+	}
+
+	if input.BillingMode != nil {
+		isProvisioned = *input.BillingMode == dynamodb.BillingModeProvisioned
+	}
+
+	throughput := input.ProvisionedThroughput
+	if throughput == nil && input.BillingMode != nil && isProvisioned {
+		throughput = &dynamodb.ProvisionedThroughput{}
+	}
+
+	if throughput != nil {
+		if throughput.ReadCapacityUnits == nil {
+			throughput.ReadCapacityUnits = current.ReadCapacityUnits
+		}
+		if throughput.WriteCapacityUnits == nil {
+			throughput.WriteCapacityUnits = current.WriteCapacityUnits
+		}
+		input.ProvisionedThroughput = throughput
+	}
+
+	for _, update := range input.GlobalSecondaryIndexUpdates {
+		if update.Create != nil && isProvisioned {
+			update.Create.ProvisionedThroughput = input.ProvisionedThroughput
+			if update.Create.ProvisionedThroughput == nil {
+				update.Create.ProvisionedThroughput = current
+			}
+		}
+	}
+}
+
+// Polls a table until it no longer exists
+func (e executor) waitForTableDeleted(name string) error {
+	start := time.Now()
+	showProgress := isTerminal(os.Stdout) && e.state.output == outputNative
+	waited := false
+
+	for {
+		input := &dynamodb.DescribeTableInput{TableName: &name}
+		_, err := e.dynamo.DescribeTable(input)
+
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+			if showProgress && waited {
+				fmt.Println()
+			}
+			return nil
+		}
+		if err != nil {
+			return newAwsError(err, input.String())
+		}
+
+		if showProgress {
+			fmt.Printf("\rWaiting for %s to be deleted (%s)\033[K", name, time.Since(start).Round(time.Second))
+		} else if !waited {
+			e.printInfo(fmt.Sprintf("Waiting for %s to be deleted", name))
+		}
+		waited = true
+
+		sleep(tablePollInterval)
+	}
+}
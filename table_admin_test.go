@@ -0,0 +1,224 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCreateTable(t *testing.T) {
+	// when
+	input, wait, err := parseCreateTable(parseArgs("Orders pk:S sk:s --gsi byCustomer customerId:S createdAt:N --lsi byStatus status:S --gsi byDate createdAt:N"))
+
+	// then
+	require.NoError(t, err)
+	require.True(t, wait)
+	require.Equal(t, &dynamodb.CreateTableInput{
+		TableName: aws.String("Orders"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("sk"), KeyType: aws.String("RANGE")},
+		},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("createdAt"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("customerId"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("pk"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("sk"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("status"), AttributeType: aws.String("S")},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("byCustomer"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("customerId"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("createdAt"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+			{
+				IndexName:  aws.String("byDate"),
+				KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String("createdAt"), KeyType: aws.String("HASH")}},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+		},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndex{{
+			IndexName: aws.String("byStatus"),
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String("status"), KeyType: aws.String("RANGE")},
+			},
+			Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+		}},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	}, input)
+}
+
+func Test_parseCreateTable_provisioned(t *testing.T) {
+	// when
+	input, wait, err := parseCreateTable(parseArgs("Orders pk:N --read 10 --gsi byCustomer customerId:S --no-wait"))
+
+	// then
+	require.NoError(t, err)
+	require.False(t, wait)
+	require.Equal(t, "PROVISIONED", *input.BillingMode)
+
+	throughput := &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(10), WriteCapacityUnits: aws.Int64(5)}
+	require.Equal(t, throughput, input.ProvisionedThroughput)
+	require.Equal(t, throughput, input.GlobalSecondaryIndexes[0].ProvisionedThroughput)
+}
+
+func Test_parseCreateTable_errors(t *testing.T) {
+	for args, expected := range map[string]string{
+		"Orders":                                   "Expected a table name and 1 or 2 keys, e.g. create-table Orders pk:S sk:S",
+		"Orders pk":                                "Invalid key pk, expected name:type where type is S, N or B",
+		"Orders pk:X":                              "Invalid key pk:X, expected name:type where type is S, N or B",
+		"Orders pk:S --gsi byPk pk:N":              "Key pk is used as both S and N",
+		"Orders pk:S --gsi byPk":                   "Missing values for --gsi, see --help",
+		"Orders pk:S --lsi byStatus status:S":      "Local secondary indexes need a table with a range key",
+		"Orders pk:S --billing free":               "Billing mode must be on-demand or provisioned",
+		"Orders pk:S --billing on-demand --read 1": "--read and --write can only be used with provisioned billing",
+		"Orders pk:S --read 0":                     "--read must be a positive number",
+		"Orders pk:S --wait":                       "unknown flag `wait'",
+	} {
+		// when
+		_, _, err := parseCreateTable(parseArgs(args))
+
+		// then
+		require.EqualError(t, err, expected, args)
+	}
+}
+
+func Test_pendingTableStatus(t *testing.T) {
+	table := &dynamodb.TableDescription{
+		TableStatus: aws.String("UPDATING"),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{IndexName: aws.String("byCustomer"), IndexStatus: aws.String("CREATING")},
+			{IndexName: aws.String("byDate"), IndexStatus: aws.String("ACTIVE")},
+		},
+	}
+	require.Equal(t, "updating, index byCustomer creating", pendingTableStatus(table))
+
+	table.TableStatus = aws.String("ACTIVE")
+	table.GlobalSecondaryIndexes[0].IndexStatus = aws.String("ACTIVE")
+	require.Equal(t, "", pendingTableStatus(table))
+}
+
+func Test_parseUpdateTable(t *testing.T) {
+	// when
+	input, rest, wait, err := parseUpdateTable(parseArgs("Orders --add-gsi byStatus status:S createdAt:N --stream new-and-old-images --read 20 --no-wait"))
+
+	// then
+	require.NoError(t, err)
+	require.False(t, wait)
+	require.Equal(t, []string{"Orders"}, rest)
+	require.Equal(t, &dynamodb.UpdateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("createdAt"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("status"), AttributeType: aws.String("S")},
+		},
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+			Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName: aws.String("byStatus"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("status"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("createdAt"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+		}},
+		StreamSpecification:   &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: aws.String("NEW_AND_OLD_IMAGES")},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(20)},
+	}, input)
+}
+
+func Test_parseUpdateTable_errors(t *testing.T) {
+	for args, expected := range map[string]string{
+		"":                              "Nothing to update, see update-table --help",
+		"--delete-gsi a --delete-gsi b": "Only one index can be added or deleted per update",
+		"--stream all":                  "Stream must be one of new-image, old-image, new-and-old-images, keys-only, disabled",
+		"--billing free":                "Billing mode must be on-demand or provisioned",
+		"--write -1":                    "Missing values for --write, see --help",
+	} {
+		// when
+		_, _, _, err := parseUpdateTable(parseArgs(args))
+
+		// then
+		require.EqualError(t, err, expected, args)
+	}
+}
+
+func Test_completeThroughput(t *testing.T) {
+	provisioned := &dynamodb.TableDescription{
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: aws.Int64(10), WriteCapacityUnits: aws.Int64(20)},
+	}
+	onDemand := &dynamodb.TableDescription{
+		BillingModeSummary:    &dynamodb.BillingModeSummary{BillingMode: aws.String("PAY_PER_REQUEST")},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: aws.Int64(0), WriteCapacityUnits: aws.Int64(0)},
+	}
+
+	// only read capacity given, write capacity is kept
+	input := &dynamodb.UpdateTableInput{ProvisionedThroughput: &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(30)}}
+	completeThroughput(input, provisioned)
+	require.Equal(t, &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(30), WriteCapacityUnits: aws.Int64(20)}, input.ProvisionedThroughput)
+
+	// switching to provisioned without a capacity
+	input = &dynamodb.UpdateTableInput{BillingMode: aws.String("PROVISIONED")}
+	completeThroughput(input, onDemand)
+	require.Equal(t, &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)}, input.ProvisionedThroughput)
+
+	// new indexes get the table's capacity, only when provisioned
+	create := &dynamodb.CreateGlobalSecondaryIndexAction{IndexName: aws.String("byStatus")}
+	input = &dynamodb.UpdateTableInput{GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{Create: create}}}
+	completeThroughput(input, provisioned)
+	require.Nil(t, input.ProvisionedThroughput)
+	require.Equal(t, &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(10), WriteCapacityUnits: aws.Int64(20)}, create.ProvisionedThroughput)
+
+	create.ProvisionedThroughput = nil
+	completeThroughput(input, onDemand)
+	require.Nil(t, create.ProvisionedThroughput)
+}
+
+func Test_updateTable_noWaitRefreshesCurrentTable(t *testing.T) {
+	// given
+	index := func(name string, status string) *dynamodb.GlobalSecondaryIndexDescription {
+		return &dynamodb.GlobalSecondaryIndexDescription{
+			IndexName:   aws.String(name),
+			IndexStatus: aws.String(status),
+			KeySchema:   []*dynamodb.KeySchemaElement{{AttributeName: aws.String(name + "Key"), KeyType: aws.String("HASH")}},
+		}
+	}
+	table := &dynamodb.TableDescription{
+		TableName:              aws.String("Orders"),
+		TableStatus:            aws.String(dynamodb.TableStatusActive),
+		BillingModeSummary:     &dynamodb.BillingModeSummary{BillingMode: aws.String(dynamodb.BillingModePayPerRequest)},
+		KeySchema:              []*dynamodb.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: aws.String("HASH")}},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{index("byCustomer", dynamodb.IndexStatusActive)},
+	}
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		if operation == "UpdateTable" {
+			table.GlobalSecondaryIndexes = []*dynamodb.GlobalSecondaryIndexDescription{
+				index("byCustomer", dynamodb.IndexStatusDeleting), index("byStatus", dynamodb.IndexStatusCreating),
+			}
+			return &dynamodb.UpdateTableOutput{TableDescription: table}
+		}
+		return &dynamodb.DescribeTableOutput{Table: table}
+	})
+	ctx := &tableContext{}
+	ctx.setTable(table)
+	ctx.attributes.learn([]map[string]*dynamodb.AttributeValue{{"pk": str("a"), "status": str("open")}})
+	e := newExecutor(dynamo, ctx, outputJsonLines, false)
+	lastRead := &pagedRead{scan: &dynamodb.ScanInput{TableName: aws.String("Orders")}, lastEvaluatedKey: map[string]*dynamodb.AttributeValue{"pk": str("a")}}
+	e.state.lastRead = lastRead
+
+	// when
+	err := e.run("update-table --add-gsi byStatus status:S --no-wait")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []string{"byStatus"}, ctx.indexes)
+	require.Equal(t, keySchema{hashAttribute: "byStatusKey"}, ctx.keys("byStatus"))
+	require.Equal(t, []string{"pk", "status"}, ctx.attributes.matching(""))
+	require.Equal(t, lastRead, e.state.lastRead)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Output of tables
+type tablesOutput struct {
+	TableNames []string
+}
+
+// Lists the tables whose names match a glob pattern, e.g. "orders-*", or start with a prefix when
+// the pattern has no wildcards. The list of tables used for completion is refreshed as well.
+func (e executor) handleTables(pattern string) error {
+	if err := e.refreshTables(); err != nil {
+		return err
+	}
+
+	names, err := matchTables(e.tableCtx.allTables, strings.TrimSpace(pattern))
+	if err != nil {
+		return err
+	}
+
+	return e.printOutput(&tablesOutput{TableNames: names})
+}
+
+func (e executor) handleRefresh() error {
+	if err := e.refreshTables(); err != nil {
+		return err
+	}
+
+	e.printInfo(fmt.Sprintf("Found %d tables", len(e.tableCtx.allTables)))
+
+	return nil
+}
+
+// Reloads the list of all tables
+func (e executor) refreshTables() error {
+	tables, err := listAllTables(e.dynamo.ListTables)
+	if err != nil {
+		return err
+	}
+
+	e.tableCtx.allTables = tables
+
+	return nil
+}
+
+// Lists all tables, following LastEvaluatedTableName as ListTables returns at most 100 per request
+func listAllTables(listTables func(*dynamodb.ListTablesInput) (*dynamodb.ListTablesOutput, error)) ([]*string, error) {
+	tables := []*string{}
+	input := &dynamodb.ListTablesInput{}
+
+	for {
+		output, err := listTables(input)
+		if err != nil {
+			return nil, newAwsError(err, input.String())
+		}
+
+		tables = append(tables, output.TableNames...)
+
+		if output.LastEvaluatedTableName == nil {
+			return tables, nil
+		}
+
+		input = &dynamodb.ListTablesInput{ExclusiveStartTableName: output.LastEvaluatedTableName}
+	}
+}
+
+func matchTables(tables []*string, pattern string) ([]string, error) {
+	isGlob := strings.ContainsAny(pattern, "*?[")
+
+	if _, err := path.Match(pattern, ""); isGlob && err != nil {
+		return nil, newValidationError("Invalid pattern: %s", pattern)
+	}
+
+	names := []string{}
+	for _, table := range tables {
+		var matched bool
+		if isGlob {
+			matched, _ = path.Match(pattern, *table)
+		} else {
+			matched = strings.HasPrefix(*table, pattern)
+		}
+
+		if matched {
+			names = append(names, *table)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_listAllTables_pages(t *testing.T) {
+	// given
+	pages := map[string]*dynamodb.ListTablesOutput{
+		"":  {TableNames: aws.StringSlice([]string{"a", "b"}), LastEvaluatedTableName: aws.String("b")},
+		"b": {TableNames: aws.StringSlice([]string{"c"})},
+	}
+	listTables := func(input *dynamodb.ListTablesInput) (*dynamodb.ListTablesOutput, error) {
+		return pages[aws.StringValue(input.ExclusiveStartTableName)], nil
+	}
+
+	// when
+	tables, err := listAllTables(listTables)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, aws.StringValueSlice(tables))
+}
+
+func Test_matchTables(t *testing.T) {
+	tables := aws.StringSlice([]string{"orders", "orders-archive", "customers", "dev-orders"})
+
+	for pattern, expected := range map[string][]string{
+		"":         {"orders", "orders-archive", "customers", "dev-orders"},
+		"orders":   {"orders", "orders-archive"},
+		"*orders":  {"orders", "dev-orders"},
+		"*ers*":    {"orders", "orders-archive", "customers", "dev-orders"},
+		"?ustomer": {},
+	} {
+		// when
+		matched, err := matchTables(tables, pattern)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, expected, matched, pattern)
+	}
+
+	_, err := matchTables(tables, "orders[")
+	require.EqualError(t, err, "Invalid pattern: orders[")
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const maxTransactionItems = 100
+
+// Writes buffered between "begin" and "commit"
+type transaction struct {
+	items    []*dynamodb.TransactWriteItem
+	commands []string // the command that buffered each item, used to show why a transaction was cancelled
+}
+
+type checkOpts struct {
+	Key                 string `short:"k" long:"key" description:"Key as a map" required:"true"`
+	ConditionExpression string `short:"c" long:"condition-expression" description:"Condition expression" required:"true"`
+	ReturnValues        bool   `short:"v" long:"return-values" description:"Return the item if the condition fails" required:"false"`
+}
+
+type transactGetOpts struct {
+	Keys             string `short:"k" long:"keys" description:"List of keys, or a map of table names to lists of keys" required:"false"`
+	File             string `long:"file" description:"File containing keys, in the same format as --keys or one key per line" required:"false"`
+	Projection       string `short:"p" long:"projection" description:"Projection expression" required:"false"`
+	ConsumedCapacity string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
+}
+
+// Output of transact-get. Items are in the order they were requested in, without the ones that
+// weren't found.
+type transactGetOutput struct {
+	Items            []map[string]*dynamodb.AttributeValue
+	ConsumedCapacity []*dynamodb.ConsumedCapacity
+}
+
+type commitOpts struct {
+	ConsumedCapacity            string `short:"r" long:"return-consumed-capacity" description:"Return consumed capacity" required:"false"`
+	ReturnItemCollectionMetrics bool   `short:"s" long:"return-item-collection-metrics" description:"Return modified collection sizes" required:"false"`
+}
+
+func (e executor) handleBegin() error {
+	if e.state.transaction != nil {
+		return newValidationError("A transaction is already in progress, commit or rollback first")
+	}
+
+	e.state.transaction = &transaction{}
+	e.printInfo("Transaction started, put, update, delete and check are buffered until commit")
+
+	return nil
+}
+
+func (e executor) handleRollback() error {
+	if e.state.transaction == nil {
+		return newValidationError("No transaction in progress")
+	}
+
+	e.printInfo(fmt.Sprintf("Discarded %d operations", len(e.state.transaction.items)))
+	e.state.transaction = nil
+
+	return nil
+}
+
+// Sends the buffered writes as a single TransactWriteItems request. The transaction ends whether or
+// not it succeeds.
+func (e executor) handleCommit(args string) error {
+	if e.state.transaction == nil {
+		return newValidationError("No transaction in progress")
+	}
+
+	commitOpts := commitOpts{}
+
+	if proceed, err := parseFlags(&commitOpts, args); !proceed {
+		return err
+	}
+
+	tx := e.state.transaction
+	if len(tx.items) == 0 {
+		e.state.transaction = nil
+		e.printInfo("Nothing to commit")
+		return nil
+	}
+
+	input := dynamodb.TransactWriteItemsInput{TransactItems: tx.items}
+
+	if commitOpts.ConsumedCapacity != "" {
+		input.SetReturnConsumedCapacity(commitOpts.ConsumedCapacity)
+	}
+
+	if commitOpts.ReturnItemCollectionMetrics {
+		input.SetReturnItemCollectionMetrics("SIZE")
+	}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	e.state.transaction = nil
+
+	output, err := e.dynamo.TransactWriteItems(&input)
+	if err != nil {
+		var canceled *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return &awsError{
+				code:    canceled.Code(),
+				message: describeCancellation(canceled.CancellationReasons, tx.commands, e.state.binary),
+				input:   input.String(),
+			}
+		}
+		return newAwsError(err, input.String())
+	}
+
+	return e.printOutput(output)
+}
+
+// Reads items from one or more tables as a single consistent snapshot, with TransactGetItems
+func (e executor) handleTransactGet(args string) error {
+	transactGetOpts := transactGetOpts{}
+
+	if proceed, err := parseFlags(&transactGetOpts, args); !proceed {
+		return err
+	}
+
+	keys, err := e.readTableMaps(transactGetOpts.Keys, "--keys", transactGetOpts.File)
+	if err != nil {
+		return err
+	}
+	keys = uniqueKeys(keys)
+
+	if len(keys) == 0 {
+		return newValidationError("No keys given, use --keys or --file")
+	}
+	if len(keys) > maxTransactionItems {
+		return newValidationError("Transactions can't read more than %d items", maxTransactionItems)
+	}
+
+	var proj *string
+	var names map[string]*string
+
+	if transactGetOpts.Projection != "" {
+		exprParser := newExprParser()
+
+		proj, err = exprParser.parseProjectionExpression(transactGetOpts.Projection)
+		if err != nil {
+			return withFlag(err, "--projection")
+		}
+		names = exprParser.getNames()
+	}
+
+	input := dynamodb.TransactGetItemsInput{}
+	for i := range keys {
+		input.TransactItems = append(input.TransactItems, &dynamodb.TransactGetItem{Get: &dynamodb.Get{
+			TableName:                &keys[i].table,
+			Key:                      keys[i].key,
+			ProjectionExpression:     proj,
+			ExpressionAttributeNames: names,
+		}})
+	}
+
+	if transactGetOpts.ConsumedCapacity != "" {
+		input.SetReturnConsumedCapacity(transactGetOpts.ConsumedCapacity)
+	}
+
+	if e.verbose {
+		fmt.Printf("DEBUG input: %v\n", input)
+	}
+
+	output, err := e.dynamo.TransactGetItems(&input)
+	if err != nil {
+		return newAwsError(err, input.String())
+	}
+
+	result := transactGetOutput{Items: []map[string]*dynamodb.AttributeValue{}, ConsumedCapacity: output.ConsumedCapacity}
+	for _, response := range output.Responses {
+		if response.Item != nil {
+			result.Items = append(result.Items, response.Item)
+		}
+	}
+
+	if missing := describeMissingItems(keys, output.Responses, e.state.binary); missing != "" {
+		e.printInfo(missing)
+	}
+
+	return e.printOutput(&result)
+}
+
+// Lists the keys whose items weren't found, as the output only has the items which were. responses
+// are in the same order as keys. Empty when every item was found.
+func describeMissingItems(keys []tableKey, responses []*dynamodb.ItemResponse, binary string) string {
+	missing := []string{}
+	for i, key := range keys {
+		if i >= len(responses) || responses[i].Item == nil {
+			missing = append(missing, fmt.Sprintf("\n  %s %s", key.table, formatItem(key.key, binary)))
+		}
+	}
+
+	if len(missing) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d of %d items were not found:", len(missing), len(keys)) + strings.Join(missing, "")
+}
+
+// Condition checks can only be part of a transaction
+func (e executor) handleCheck(args string) error {
+	if err := e.validateTableSelected(); err != nil {
+		return err
+	}
+
+	if e.state.transaction == nil {
+		return newValidationError("check can only be used in a transaction, use begin first")
+	}
+
+	checkOpts := checkOpts{}
+
+	if proceed, err := parseFlags(&checkOpts, args); !proceed {
+		return err
+	}
+
+	keyMap, err := parseItem(checkOpts.Key)
+	if err != nil {
+		return withFlag(err, "--key")
+	}
+
+	exprParser := newExprParser()
+
+	condition, err := exprParser.parseConditionExpression(checkOpts.ConditionExpression)
+	if err != nil {
+		return withFlag(err, "--condition-expression")
+	}
+
+	check := &dynamodb.ConditionCheck{
+		TableName:                 aws.String(e.tableCtx.name),
+		Key:                       keyMap,
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  exprParser.getNames(),
+		ExpressionAttributeValues: exprParser.getValues(),
+	}
+
+	if checkOpts.ReturnValues {
+		check.SetReturnValuesOnConditionCheckFailure("ALL_OLD")
+	}
+
+	return e.bufferWrite("check "+args, &dynamodb.TransactWriteItem{ConditionCheck: check})
+}
+
+// Adds a write to the current transaction instead of running it
+func (e executor) bufferWrite(command string, item *dynamodb.TransactWriteItem) error {
+	tx := e.state.transaction
+	if len(tx.items) >= maxTransactionItems {
+		return newValidationError("Transactions can't contain more than %d operations", maxTransactionItems)
+	}
+
+	tx.items = append(tx.items, item)
+	tx.commands = append(tx.commands, command)
+	e.printInfo(fmt.Sprintf("Added to transaction (%d operations)", len(tx.items)))
+
+	return nil
+}
+
+// Single item write flags which don't apply inside a transaction. When the condition fails,
+// --return-values returns the item as it was.
+func transactionReturnValues(opts writeOpts) (*string, error) {
+	if opts.ConsumedCapacity != "" || opts.ReturnItemCollectionMetrics {
+		return nil, newValidationError("In a transaction, consumed capacity and item collection metrics are returned by commit")
+	}
+
+	if opts.ReturnValues {
+		return aws.String("ALL_OLD"), nil
+	}
+
+	return nil, nil
+}
+
+// Lists the operations which caused a transaction to be cancelled, with the reason for each
+func describeCancellation(reasons []*dynamodb.CancellationReason, commands []string, binary string) string {
+	var sb strings.Builder
+	sb.WriteString("Transaction cancelled")
+
+	for i, reason := range reasons {
+		if reason.Code == nil || *reason.Code == "None" || i >= len(commands) {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n  %d. %s\n     %s", i+1, commands[i], *reason.Code)
+		if reason.Message != nil {
+			sb.WriteString(": " + *reason.Message)
+		}
+		if reason.Item != nil {
+			sb.WriteString("\n     Item: " + formatItem(reason.Item, binary))
+		}
+	}
+
+	return sb.String()
+}
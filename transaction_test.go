@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_describeCancellation(t *testing.T) {
+	// given
+	reasons := []*dynamodb.CancellationReason{
+		{Code: aws.String("None")},
+		{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed"),
+			Item: map[string]*dynamodb.AttributeValue{"pk": str("b")}},
+		{Code: aws.String("TransactionConflict")},
+	}
+	commands := []string{`put -i "{ pk: 'a' }"`, `check -k "{ pk: 'b' }" -c "attribute_not_exists(pk)" -v`, `delete -k "{ pk: 'c' }"`}
+
+	// when
+	description := describeCancellation(reasons, commands, binaryBase64)
+
+	// then
+	require.Equal(t, "Transaction cancelled\n"+
+		"  2. check -k \"{ pk: 'b' }\" -c \"attribute_not_exists(pk)\" -v\n"+
+		"     ConditionalCheckFailed: The conditional request failed\n"+
+		"     Item: { pk: 'b' }\n"+
+		"  3. delete -k \"{ pk: 'c' }\"\n"+
+		"     TransactionConflict", description)
+}
+
+func Test_transactionReturnValues(t *testing.T) {
+	returnValues, err := transactionReturnValues(writeOpts{ReturnValues: true})
+	require.NoError(t, err)
+	require.Equal(t, "ALL_OLD", *returnValues)
+
+	_, err = transactionReturnValues(writeOpts{ConsumedCapacity: "TOTAL"})
+	require.Equal(t, exitValidation, exitCode(err))
+}
+
+func Test_transactGet_keepsRequestOrder(t *testing.T) {
+	// given
+	requested := []string{}
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		output := &dynamodb.TransactGetItemsOutput{}
+		for _, item := range params.(*dynamodb.TransactGetItemsInput).TransactItems {
+			key := formatValue(&dynamodb.AttributeValue{M: item.Get.Key}, binaryBase64)
+			requested = append(requested, *item.Get.TableName+" "+key)
+
+			response := &dynamodb.ItemResponse{}
+			if key != "{ pk: 'a' }" {
+				response.Item = item.Get.Key
+			}
+			output.Responses = append(output.Responses, response)
+		}
+		return output
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders"}, outputJsonLines, false)
+
+	// when
+	var err error
+	out := captureStdout(t, func() {
+		err = e.run(`transact-get -k "{ Orders: [{ pk: 'b' }, { pk: 'a' }, { pk: 'c' }], Customers: [{ id: 1 }] }"`)
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []string{"Customers { id: 1 }", "Orders { pk: 'b' }", "Orders { pk: 'a' }", "Orders { pk: 'c' }"}, requested)
+	require.Equal(t, "{\"id\":1}\n{\"pk\":\"b\"}\n{\"pk\":\"c\"}\n", out)
+}
+
+func Test_describeMissingItems(t *testing.T) {
+	// given
+	keys := []tableKey{
+		{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": str("a")}},
+		{table: "Orders", key: map[string]*dynamodb.AttributeValue{"pk": str("b")}},
+		{table: "Customers", key: map[string]*dynamodb.AttributeValue{"id": integer(1)}},
+	}
+	found := &dynamodb.ItemResponse{Item: map[string]*dynamodb.AttributeValue{"pk": str("x")}}
+
+	// when
+	missingMiddle := describeMissingItems(keys, []*dynamodb.ItemResponse{found, {}, found}, binaryBase64)
+	allFound := describeMissingItems(keys, []*dynamodb.ItemResponse{found, found, found}, binaryBase64)
+
+	// then
+	require.Equal(t, "1 of 3 items were not found:\n  Orders { pk: 'b' }", missingMiddle)
+	require.Empty(t, allFound)
+}
+
+func Test_transaction_keepsTableOfBufferedWrites(t *testing.T) {
+	// given
+	var committed *dynamodb.TransactWriteItemsInput
+	dynamo := mockDynamo(func(operation string, params interface{}) interface{} {
+		switch operation {
+		case "DescribeTable":
+			return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+				TableName: params.(*dynamodb.DescribeTableInput).TableName,
+				KeySchema: []*dynamodb.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: aws.String("HASH")}},
+			}}
+		default:
+			committed = params.(*dynamodb.TransactWriteItemsInput)
+			return &dynamodb.TransactWriteItemsOutput{}
+		}
+	})
+	e := newExecutor(dynamo, &tableContext{name: "Orders", hashAttribute: "pk"}, outputJsonLines, false)
+
+	// when
+	for _, command := range []string{"begin", `put -i "{ pk: 'a' }"`, "use Other", `delete -k "{ id: 1 }"`, "commit"} {
+		require.NoError(t, e.run(command))
+	}
+
+	// then
+	require.Equal(t, "Orders", *committed.TransactItems[0].Put.TableName)
+	require.Equal(t, "Other", *committed.TransactItems[1].Delete.TableName)
+}
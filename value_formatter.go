@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	binaryBase64 = "base64"
+	binaryHex    = "hex"
+)
+
+var binaryEncodings []string = []string{binaryBase64, binaryHex}
+
+// Formats an item using the same syntax that values are written in, so that it can be used as input
+// for put, e.g. { key1: 'value1', key2: 123 }. Binary values are written in the given encoding.
+func formatItem(item map[string]*dynamodb.AttributeValue, binary string) string {
+	return formatValue(&dynamodb.AttributeValue{M: item}, binary)
+}
+
+func formatValue(value *dynamodb.AttributeValue, binary string) string {
+	var sb strings.Builder
+	formatValue0(value, binary, &sb)
+	return sb.String()
+}
+
+func formatValue0(value *dynamodb.AttributeValue, binary string, sb *strings.Builder) {
+	switch {
+	case value == nil:
+		sb.WriteString("NULL")
+	case value.S != nil:
+		sb.WriteString(formatString(*value.S))
+	case value.N != nil:
+		sb.WriteString(*value.N)
+	case value.BOOL != nil:
+		fmt.Fprintf(sb, "%t", *value.BOOL)
+	case value.NULL != nil:
+		sb.WriteString("NULL")
+	case value.B != nil:
+		sb.WriteString(formatBinary(value.B, binary))
+	case value.SS != nil:
+		strs := []string{}
+		for _, s := range value.SS {
+			strs = append(strs, formatString(*s))
+		}
+		sb.WriteString("<<" + strings.Join(strs, ", ") + ">>")
+	case value.NS != nil:
+		nums := []string{}
+		for _, n := range value.NS {
+			nums = append(nums, *n)
+		}
+		sb.WriteString("<<" + strings.Join(nums, ", ") + ">>")
+	case value.BS != nil:
+		bins := []string{}
+		for _, b := range value.BS {
+			bins = append(bins, formatBinary(b, binary))
+		}
+		sb.WriteString("<<" + strings.Join(bins, ", ") + ">>")
+	case value.L != nil:
+		sb.WriteString("[")
+		for i, v := range value.L {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			formatValue0(v, binary, sb)
+		}
+		sb.WriteString("]")
+	case value.M != nil:
+		if len(value.M) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+
+		keys := []string{}
+		for k := range value.M {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		sb.WriteString("{ ")
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(formatName(k) + ": ")
+			formatValue0(value.M[k], binary, sb)
+		}
+		sb.WriteString(" }")
+	default:
+		sb.WriteString("<invalid value>")
+	}
+}
+
+// Strings are single quoted. Double quotes are escaped as well, since values are usually passed
+// inside a double quoted argument.
+func formatString(str string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `"`, `\"`)
+	return "'" + escaper.Replace(str) + "'"
+}
+
+// e.g. b64'SGVsbG8=' or x'48656c6c6f'
+func formatBinary(b []byte, binary string) string {
+	if binary == binaryHex {
+		return "x'" + encodeBinary(b, binary) + "'"
+	}
+
+	return "b64'" + encodeBinary(b, binary) + "'"
+}
+
+func encodeBinary(b []byte, binary string) string {
+	if binary == binaryHex {
+		return hex.EncodeToString(b)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Names which can't be written as they are in an expression, e.g. `first name`, are quoted with
+// backticks. Plain names are made up of letters, numbers and underscores and don't start with a number.
+func formatName(name string) string {
+	isPlain := name != "" && !unicode.IsDigit(rune(name[0]))
+	for _, char := range name {
+		if !isWordChar(char) {
+			isPlain = false
+		}
+	}
+
+	if isPlain {
+		return name
+	}
+
+	return "`" + name + "`"
+}
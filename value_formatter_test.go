@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_format_item(t *testing.T) {
+	// given
+	item := map[string]*dynamodb.AttributeValue{
+		"Artist":    str("Metallica"),
+		"Year":      integer(1986),
+		"Rating":    float(9.5),
+		"Released":  boolean(true),
+		"Label":     {NULL: boolean(true).BOOL},
+		"Genres":    stringSet([]string{"thrash", "heavy"}),
+		"Tracks":    numberSet([]string{"1", "2"}),
+		"Songs":     {L: []*dynamodb.AttributeValue{str("Battery"), integer(8)}},
+		"Personnel": {M: map[string]*dynamodb.AttributeValue{"James Hetfield": str("vocals")}},
+	}
+
+	// when
+	formatted := formatItem(item, binaryBase64)
+
+	// then
+	require.Equal(t, "{ Artist: 'Metallica', Genres: <<'thrash', 'heavy'>>, Label: NULL, "+
+		"Personnel: { `James Hetfield`: 'vocals' }, Rating: 9.5, Released: true, "+
+		"Songs: ['Battery', 8], Tracks: <<1, 2>>, Year: 1986 }", formatted)
+}
+
+func Test_format_stringEscaping(t *testing.T) {
+	require.Equal(t, `'It\'s'`, formatValue(str("It's"), binaryBase64))
+	require.Equal(t, `'\\'`, formatValue(str(`\`), binaryBase64))
+	require.Equal(t, `'\"quoted\"'`, formatValue(str(`"quoted"`), binaryBase64))
+}
+
+func Test_format_roundTrip(t *testing.T) {
+	// given
+	item := map[string]*dynamodb.AttributeValue{
+		"pk":        str("It's a \"string\" \\"),
+		"number":    float(123.456),
+		"set":       stringSet([]string{"a", "b"}),
+		"list":      {L: []*dynamodb.AttributeValue{integer(1), {L: []*dynamodb.AttributeValue{}}}},
+		"a b:c":     {M: map[string]*dynamodb.AttributeValue{"nested": boolean(false)}},
+		"emptyMap":  {M: map[string]*dynamodb.AttributeValue{}},
+		"binary":    {B: []byte{0, 1, 255}},
+		"binarySet": {BS: [][]byte{{1}, {2}}},
+	}
+
+	for _, binary := range binaryEncodings {
+		// when
+		parsed, err := parseItem(formatItem(item, binary))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, item, parsed)
+	}
+}
+
+func Test_format_binary(t *testing.T) {
+	// given
+	value := &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+		"b":  {B: []byte("Hello")},
+		"bs": {BS: [][]byte{{1}, {2}}},
+	}}
+
+	// then
+	require.Equal(t, "{ b: b64'SGVsbG8=', bs: <<b64'AQ==', b64'Ag=='>> }", formatValue(value, binaryBase64))
+	require.Equal(t, "{ b: x'48656c6c6f', bs: <<x'01', x'02'>> }", formatValue(value, binaryHex))
+}
+
+func Test_formatName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"pk":         "pk",
+		"created_at": "created_at",
+		"first name": "`first name`",
+		"1st":        "`1st`",
+		"a-b":        "`a-b`",
+		"año":        "año",
+	} {
+		require.Equal(t, expected, formatName(name))
+	}
+}